@@ -0,0 +1,214 @@
+// Package auditlog records every unit write to a rotating JSON Lines file so
+// it can be checked later what was written, when, and whether it stuck.
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the name of the log file in the config directory.
+const FileName = "ms-changer-audit.jsonl"
+
+// DefaultPath returns the log file used by the CLIs and the GUI: next to
+// the settings file in the user's config directory, so it is the same
+// file whichever directory they are started from.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ms-changer", FileName), nil
+}
+
+const (
+	defaultMaxSize = 1 << 20 // rotate after 1 MiB
+	defaultKeep    = 5       // rotated files kept next to the log
+)
+
+// Results of a write.
+const (
+	ResultOK       = "ok"       // written and read back
+	ResultReverted = "reverted" // read back differs from what was written
+	ResultFailed   = "failed"   // the write itself failed
+)
+
+type Entry struct {
+	Time     time.Time `json:"time"`
+	PID      uint32    `json:"pid"`
+	Profile  string    `json:"profile"`
+	UnitID   int32     `json:"unit_id,omitempty"`
+	UnitName string    `json:"unit_name,omitempty"`
+	Value    int32     `json:"value"`
+	Previous int32     `json:"previous"`
+	Result   string    `json:"result"`
+	Error    string    `json:"error,omitempty"`
+	// LatencyUS is how long the write and read-back took, in microseconds.
+	LatencyUS int64 `json:"latency_us"`
+}
+
+func (e Entry) String() string {
+	name := e.UnitName
+	if name == "" {
+		name = strconv.Itoa(int(e.Value))
+	}
+	s := fmt.Sprintf("%s PID %d %s: %d -> %d (%s) %s, %dµs",
+		e.Time.Format(time.DateTime), e.PID, e.Profile, e.Previous, e.Value, name, e.Result, e.LatencyUS)
+	if e.Error != "" {
+		s += ": " + e.Error
+	}
+	return s
+}
+
+// Logger appends entries to a file, rotating it to path.1, path.2, ... once
+// it grows past MaxSize. It is safe for concurrent use, also by several
+// processes: the GUI, the GUI CLI it starts and the CLI can all write the
+// same log, so every write takes a lock on path.lock and opens the file
+// afresh instead of holding a handle another process may rotate away.
+type Logger struct {
+	Path    string
+	MaxSize int64
+	Keep    int
+
+	mu sync.Mutex
+}
+
+// Open returns a Logger for path, creating the file and its directory.
+func Open(path string) (*Logger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return &Logger{Path: path, MaxSize: defaultMaxSize, Keep: defaultKeep}, nil
+}
+
+// OpenDefault opens the log at DefaultPath.
+func OpenDefault() (*Logger, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return Open(path)
+}
+
+func (l *Logger) Write(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lf, err := os.OpenFile(l.Path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer lf.Close() // releases the lock
+	if err := lock(lf); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(l.Path); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > l.MaxSize {
+		l.rotate()
+	}
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (l *Logger) rotate() {
+	os.Remove(rotated(l.Path, l.Keep))
+	for i := l.Keep - 1; i >= 1; i-- {
+		os.Rename(rotated(l.Path, i), rotated(l.Path, i+1))
+	}
+	if l.Keep > 0 {
+		os.Rename(l.Path, rotated(l.Path, 1))
+	} else {
+		os.Remove(l.Path)
+	}
+}
+
+func rotated(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// Read returns the entries of the log and its rotated files, oldest first.
+// Lines that cannot be parsed are skipped.
+func Read(path string) ([]Entry, error) {
+	files := []string{path}
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated(path, i)); err != nil {
+			break
+		}
+		files = append([]string{rotated(path, i)}, files...)
+	}
+
+	var entries []Entry
+	for _, name := range files {
+		f, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var e Entry
+			if json.Unmarshal(sc.Bytes(), &e) == nil {
+				entries = append(entries, e)
+			}
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Tail returns the last n entries.
+func Tail(entries []Entry, n int) []Entry {
+	if len(entries) > n {
+		return entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// Query keeps the entries written at or after since (zero for all) that match
+// unit: a unit id, a value, or part of a unit name (empty for all).
+func Query(entries []Entry, since time.Time, unit string) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		if e.Time.Before(since) {
+			continue
+		}
+		if unit != "" && strconv.Itoa(int(e.UnitID)) != unit && strconv.Itoa(int(e.Value)) != unit &&
+			!strings.Contains(strings.ToLower(e.UnitName), strings.ToLower(unit)) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
@@ -0,0 +1,170 @@
+package auditlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.MaxSize, l.Keep = 400, 2
+
+	for i := 0; i < 20; i++ {
+		if err := l.Write(Entry{Profile: "test", Value: int32(i), Result: ResultOK}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{path, rotated(path, 1), rotated(path, 2)} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > l.MaxSize {
+			t.Errorf("%s is %d bytes, over MaxSize", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(rotated(path, 3)); err == nil {
+		t.Error("kept more than Keep rotated files")
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || entries[len(entries)-1].Value != 19 {
+		t.Fatalf("Read = %v, want the newest entry last", entries)
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Value != entries[i-1].Value+1 {
+			t.Errorf("entries out of order at %d: %d after %d", i, entries[i].Value, entries[i-1].Value)
+		}
+	}
+}
+
+// TestConcurrentLoggers writes through several Loggers on the same file, as
+// the GUI, the GUI CLI and the CLI do. Rotation must not lose or duplicate
+// entries.
+func TestConcurrentLoggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	const loggers, writes = 8, 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < loggers; i++ {
+		l, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.MaxSize, l.Keep = 2000, 100
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				if err := l.Write(Entry{PID: uint32(i), Value: int32(j), Result: ResultOK}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, e := range entries {
+		key := fmt.Sprint(e.PID, e.Value)
+		if seen[key] {
+			t.Errorf("entry %s logged twice", key)
+		}
+		seen[key] = true
+	}
+	if len(seen) != loggers*writes {
+		t.Errorf("got %d entries, want %d", len(seen), loggers*writes)
+	}
+	// Only one Logger rotates when the file is full, so every rotated file
+	// is full too, short of one entry.
+	for i := 1; ; i++ {
+		info, err := os.Stat(rotated(path, i))
+		if err != nil {
+			break
+		}
+		if info.Size() < 2000-200 {
+			t.Errorf("%s is only %d bytes: rotated twice", rotated(path, i), info.Size())
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	day := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: day, UnitID: 1, UnitName: "機動戦士ガンダム / ガンダム", Value: 1001001},
+		{Time: day.Add(time.Hour), UnitID: 2, UnitName: "Zガンダム / Zガンダム", Value: 1002001},
+		{Time: day.Add(2 * time.Hour), Value: 1003001},
+	}
+	tests := []struct {
+		since time.Time
+		unit  string
+		want  []int32
+	}{
+		{time.Time{}, "", []int32{1001001, 1002001, 1003001}},
+		{day.Add(time.Hour), "", []int32{1002001, 1003001}},
+		{time.Time{}, "2", []int32{1002001}},
+		{time.Time{}, "1003001", []int32{1003001}},
+		{time.Time{}, "ガンダム", []int32{1001001, 1002001}},
+		{time.Time{}, "zガンダム", []int32{1002001}},
+		{day.Add(time.Hour), "機動戦士", nil},
+	}
+	for _, tt := range tests {
+		var got []int32
+		for _, e := range Query(entries, tt.since, tt.unit) {
+			got = append(got, e.Value)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Query(%v, %q) = %v, want %v", tt.since, tt.unit, got, tt.want)
+		}
+	}
+	if got := Tail(entries, 2); len(got) != 2 || got[1].Value != 1003001 {
+		t.Errorf("Tail(2) = %v", got)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config) // Linux
+	t.Setenv("AppData", config)         // Windows
+	t.Setenv("HOME", config)            // macOS
+	t.Chdir(t.TempDir())
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := os.UserConfigDir()
+	if want := filepath.Join(dir, "ms-changer", FileName); path != want || !strings.HasPrefix(path, config) {
+		t.Errorf("DefaultPath = %s, want %s", path, want)
+	}
+	l, err := OpenDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Write(Entry{Value: 1001001, Result: ResultOK}); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := Read(path); err != nil || len(entries) != 1 {
+		t.Errorf("Read(%s) = %v, %v, want the entry", path, entries, err)
+	}
+	if _, err := os.Stat(FileName); !os.IsNotExist(err) {
+		t.Error("the log was written to the working directory")
+	}
+}
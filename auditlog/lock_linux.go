@@ -0,0 +1,14 @@
+//go:build linux
+// +build linux
+
+package auditlog
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock waits for an exclusive flock on f; closing f releases it.
+func lock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
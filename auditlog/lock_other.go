@@ -0,0 +1,12 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package auditlog
+
+import "os"
+
+// lock is only implemented on Windows and Linux; elsewhere writes from
+// several processes are not serialised.
+func lock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package auditlog
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lock waits for the first byte of f; closing f releases it.
+func lock(f *os.File) error {
+	var ol windows.Overlapped
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &ol)
+}
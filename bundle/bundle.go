@@ -0,0 +1,303 @@
+// Package bundle verifies and installs database bundles: a zip with a units
+// file, pointer profiles and a manifest listing their SHA-256 hashes,
+// optionally signed with ed25519.
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ms-changer/profile"
+)
+
+const (
+	ManifestFile  = "manifest.json"
+	SignatureFile = "manifest.sig" // base64 ed25519 signature of manifest.json
+	InstalledFile = "bundle.json"  // manifest of the installed bundle
+	BackupDir     = "backups"
+)
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Version string            `json:"version"`
+	Units   string            `json:"units"` // which of Files is the units database
+	Files   map[string]string `json:"files"` // name -> hex SHA-256
+}
+
+// Bundle is an opened bundle. Files holds the contents of every file the
+// manifest lists.
+type Bundle struct {
+	Manifest  Manifest
+	Files     map[string][]byte
+	Signed    bool // a signature was present
+	Verified  bool // and it matched one of the trusted keys
+	manifest  []byte
+	signature []byte
+}
+
+// Open reads a bundle and checks that its files match the manifest.
+func Open(filename string) (*Bundle, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	b := &Bundle{Files: map[string][]byte{}}
+	contents := map[string][]byte{}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !safeName(f.Name) {
+			return nil, fmt.Errorf("%s: unexpected file %q (bundles are flat)", filename, f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, 64<<20))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name, err)
+		}
+		contents[f.Name] = data
+	}
+
+	var ok bool
+	if b.manifest, ok = contents[ManifestFile]; !ok {
+		return nil, fmt.Errorf("%s: no %s", filename, ManifestFile)
+	}
+	if err := json.Unmarshal(b.manifest, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("%s: %v", ManifestFile, err)
+	}
+	m := b.Manifest
+	if m.Version == "" {
+		return nil, fmt.Errorf("%s: version is missing", ManifestFile)
+	}
+	if _, ok := m.Files[m.Units]; !ok {
+		return nil, fmt.Errorf("%s: units file %q is not listed in files", ManifestFile, m.Units)
+	}
+	if sig, ok := contents[SignatureFile]; ok {
+		if b.signature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig))); err != nil {
+			return nil, fmt.Errorf("%s: %v", SignatureFile, err)
+		}
+		b.Signed = true
+	}
+
+	for name, want := range m.Files {
+		if !safeName(name) || name == ManifestFile || name == SignatureFile || name == InstalledFile {
+			return nil, fmt.Errorf("%s: file name %q is not allowed", ManifestFile, name)
+		}
+		data, ok := contents[name]
+		if !ok {
+			return nil, fmt.Errorf("%s lists %s, but the bundle does not contain it", ManifestFile, name)
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), want) {
+			return nil, fmt.Errorf("%s: SHA-256 mismatch", name)
+		}
+		b.Files[name] = data
+		if IsProfile(m, name) {
+			if _, err := profile.Parse(data, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for name := range contents {
+		if _, ok := m.Files[name]; !ok && name != ManifestFile && name != SignatureFile {
+			return nil, fmt.Errorf("%s is not listed in %s", name, ManifestFile)
+		}
+	}
+	return b, nil
+}
+
+// IsProfile reports whether a file of the bundle is a pointer profile:
+// every JSON file other than the units database is one.
+func IsProfile(m Manifest, name string) bool {
+	return name != m.Units && strings.EqualFold(filepath.Ext(name), ".json")
+}
+
+// Verify checks the signature against the trusted keys. Without keys an
+// unsigned or unverifiable bundle is accepted (Verified stays false); with
+// keys the bundle must be signed by one of them.
+func (b *Bundle) Verify(keys []ed25519.PublicKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if !b.Signed {
+		return fmt.Errorf("bundle is not signed")
+	}
+	for _, k := range keys {
+		if ed25519.Verify(k, b.manifest, b.signature) {
+			b.Verified = true
+			return nil
+		}
+	}
+	return fmt.Errorf("signature does not match any trusted key")
+}
+
+// ParseKey decodes a base64 ed25519 public key.
+func ParseKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Installed returns the manifest of the bundle installed in dir, or nil.
+func Installed(dir string) *Manifest {
+	data, err := os.ReadFile(filepath.Join(dir, InstalledFile))
+	if err != nil {
+		return nil
+	}
+	var m Manifest
+	if json.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	return &m
+}
+
+// Install writes the bundle's files into dir. The files it replaces are
+// copied to a new directory under dir/backups first, which is returned; if
+// anything fails, everything already done is undone.
+func (b *Bundle) Install(dir string) (string, error) {
+	files := map[string][]byte{InstalledFile: b.manifest}
+	for name, data := range b.Files {
+		files[name] = data
+	}
+	// Named after the time so Rollback finds the latest by sorting; the
+	// random suffix keeps two installs in the same instant apart.
+	if err := os.MkdirAll(filepath.Join(dir, BackupDir), 0755); err != nil {
+		return "", err
+	}
+	backup, err := os.MkdirTemp(filepath.Join(dir, BackupDir), time.Now().Format("20060102-150405.000000000-"))
+	if err != nil {
+		return "", err
+	}
+	if err := replace(dir, backup, files); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// Rollback restores the most recent backup in dir and removes it. It
+// returns the backup that was restored.
+func Rollback(dir string) (string, error) {
+	backups, _ := filepath.Glob(filepath.Join(dir, BackupDir, "*"))
+	sort.Strings(backups)
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups in %s", filepath.Join(dir, BackupDir))
+	}
+	latest := backups[len(backups)-1]
+	entries, err := os.ReadDir(latest)
+	if err != nil {
+		return "", err
+	}
+	files := map[string][]byte{}
+	for _, e := range entries {
+		if files[e.Name()], err = os.ReadFile(filepath.Join(latest, e.Name())); err != nil {
+			return "", err
+		}
+	}
+	// The backup only has the files that existed before; files the bundle
+	// added are removed.
+	if m := Installed(dir); m != nil {
+		for name := range m.Files {
+			if _, ok := files[name]; !ok {
+				files[name] = nil
+			}
+		}
+	}
+	if _, ok := files[InstalledFile]; !ok {
+		files[InstalledFile] = nil
+	}
+	if err := replace(dir, "", files); err != nil {
+		return "", err
+	}
+	return latest, os.RemoveAll(latest)
+}
+
+// replace puts files into dir, a nil content meaning the file is removed.
+// Each new file is written next to its target and renamed over it, so a
+// reader never sees half a file. The old files are copied to backup
+// (unless backup is "") and written back if any step fails.
+func replace(dir, backup string, files map[string][]byte) (err error) {
+	if backup != "" {
+		if err := os.MkdirAll(backup, 0755); err != nil {
+			return err
+		}
+	}
+	var undo []func()
+	defer func() {
+		if err != nil {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+			if backup != "" {
+				os.RemoveAll(backup)
+			}
+		}
+	}()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := filepath.Join(dir, name)
+		if data := files[name]; data != nil {
+			if err := os.WriteFile(target+".new", data, 0644); err != nil {
+				return err
+			}
+			undo = append(undo, func() { os.Remove(target + ".new") })
+		}
+	}
+	for _, name := range names {
+		target := filepath.Join(dir, name)
+		old, err := os.ReadFile(target)
+		switch {
+		case os.IsNotExist(err):
+			undo = append(undo, func() { os.Remove(target) })
+		case err != nil:
+			return err
+		default:
+			if backup != "" {
+				if err := os.WriteFile(filepath.Join(backup, name), old, 0644); err != nil {
+					return err
+				}
+			}
+			undo = append(undo, func() { os.WriteFile(target, old, 0644) })
+		}
+		if files[name] == nil {
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(target+".new", target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func safeName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\:`)
+}
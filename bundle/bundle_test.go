@@ -0,0 +1,228 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testUnits   = "id,title,ms,value\n1,機動戦士ガンダム,ガンダム,1001001\n"
+	testProfile = `{"name": "test", "process": "game.exe", "unit": {"base": "0x10", "offsets": ["0x8"]}}`
+)
+
+// writeBundle zips files with a manifest listing them (plus extra, which is
+// added without being listed) and returns the path of the zip.
+func writeBundle(t *testing.T, files, extra map[string]string, key ed25519.PrivateKey) string {
+	t.Helper()
+	m := Manifest{Version: "1.06", Units: "units.csv", Files: map[string]string{}}
+	for name, data := range files {
+		sum := sha256.Sum256([]byte(data))
+		m.Files[name] = hex.EncodeToString(sum[:])
+	}
+	manifest, _ := json.Marshal(m)
+
+	path := filepath.Join(t.TempDir(), "bundle.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	add := func(name, data string) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	add(ManifestFile, string(manifest))
+	if key != nil {
+		add(SignatureFile, base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest)))
+	}
+	for name, data := range files {
+		add(name, data)
+	}
+	for name, data := range extra {
+		add(name, data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	valid := map[string]string{"units.csv": testUnits, "profile.json": testProfile}
+	b, err := Open(writeBundle(t, valid, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Manifest.Version != "1.06" || string(b.Files["profile.json"]) != testProfile || b.Signed {
+		t.Errorf("Open = %+v", b)
+	}
+
+	tests := []struct {
+		name         string
+		files, extra map[string]string
+		wantErr      string
+	}{
+		{"no units", map[string]string{"profile.json": testProfile}, nil, "not listed in files"},
+		{"unlisted file", valid, map[string]string{"evil.exe": "MZ"}, "not listed in manifest.json"},
+		{"profile without offsets", map[string]string{"units.csv": testUnits,
+			"profile.json": `{"unit": {"base": "0x10", "offsets": []}}`}, nil, "profile.json: unit chain has no offsets"},
+		{"profile with a bad process pattern", map[string]string{"units.csv": testUnits,
+			"exvs.json": `{"process": "re:(", "unit": {"base": "0x10", "offsets": ["0x8"]}}`}, nil, "exvs.json: invalid process regexp"},
+		{"profile that is not JSON", map[string]string{"units.csv": testUnits, "profile.json": "offsets: 8"}, nil, "profile.json:"},
+	}
+	for _, tt := range tests {
+		_, err := Open(writeBundle(t, tt.files, tt.extra, nil))
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Open error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOpenHashMismatch(t *testing.T) {
+	path := writeBundle(t, map[string]string{"units.csv": testUnits}, nil, nil)
+	// Rewrite the zip with the same manifest but different units.
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "tampered.zip")
+	f, _ := os.Create(out)
+	zw := zip.NewWriter(f)
+	for _, zf := range r.File {
+		rc, _ := zf.Open()
+		w, _ := zw.Create(zf.Name)
+		if zf.Name == "units.csv" {
+			w.Write([]byte(testUnits + "2,機動戦士ガンダム,ジオング,1004001\n"))
+		} else {
+			buf := make([]byte, zf.UncompressedSize64)
+			rc.Read(buf)
+			w.Write(buf)
+		}
+		rc.Close()
+	}
+	r.Close()
+	zw.Close()
+	f.Close()
+
+	if _, err := Open(out); err == nil || !strings.Contains(err.Error(), "SHA-256 mismatch") {
+		t.Errorf("Open = %v, want a SHA-256 mismatch", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+	files := map[string]string{"units.csv": testUnits}
+
+	signed, err := Open(writeBundle(t, files, nil, priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := Open(writeBundle(t, files, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unsigned.Verify(nil); err != nil || unsigned.Verified {
+		t.Errorf("unsigned without keys: %v, verified %v", err, unsigned.Verified)
+	}
+	if err := unsigned.Verify([]ed25519.PublicKey{pub}); err == nil {
+		t.Error("unsigned bundle accepted with a trusted key")
+	}
+	if err := signed.Verify([]ed25519.PublicKey{other}); err == nil {
+		t.Error("bundle signed by another key accepted")
+	}
+	if err := signed.Verify([]ed25519.PublicKey{other, pub}); err != nil || !signed.Verified {
+		t.Errorf("signed bundle: %v, verified %v", err, signed.Verified)
+	}
+
+	key, err := ParseKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil || !key.Equal(pub) {
+		t.Errorf("ParseKey = %v, %v", key, err)
+	}
+	if _, err := ParseKey("c2hvcnQ="); err == nil {
+		t.Error("ParseKey accepted a short key")
+	}
+}
+
+func TestInstallRollback(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "units.csv"), []byte("old units"), 0644)
+
+	b1, err := Open(writeBundle(t, map[string]string{"units.csv": testUnits, "profile.json": testProfile}, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newer := testUnits + "2,機動戦士ガンダム,ジオング,1004001\n"
+	b2, err := Open(writeBundle(t, map[string]string{"units.csv": newer}, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two installs within the same second get backups of their own.
+	backup1, err := b1.Install(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup2, err := b2.Install(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup1 == backup2 {
+		t.Fatalf("both installs backed up to %s", backup1)
+	}
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "<missing>"
+		}
+		return string(data)
+	}
+	if read("units.csv") != newer || read("profile.json") != testProfile {
+		t.Errorf("after installing: units %q, profile %q", read("units.csv"), read("profile.json"))
+	}
+	if m := Installed(dir); m == nil || len(m.Files) != 1 {
+		t.Errorf("Installed = %+v, want the second bundle", m)
+	}
+
+	if restored, err := Rollback(dir); err != nil || restored != backup2 {
+		t.Fatalf("first Rollback = %s, %v, want %s", restored, err, backup2)
+	}
+	if read("units.csv") != testUnits || read("profile.json") != testProfile {
+		t.Errorf("after one rollback: units %q, profile %q", read("units.csv"), read("profile.json"))
+	}
+	if restored, err := Rollback(dir); err != nil || restored != backup1 {
+		t.Fatalf("second Rollback = %s, %v, want %s", restored, err, backup1)
+	}
+	if read("units.csv") != "old units" || read("profile.json") != "<missing>" || read(InstalledFile) != "<missing>" {
+		t.Errorf("after two rollbacks: units %q, profile %q, %s %q", read("units.csv"), read("profile.json"), InstalledFile, read(InstalledFile))
+	}
+	if _, err := Rollback(dir); err == nil {
+		t.Error("Rollback without backups succeeded")
+	}
+}
+
+func TestIsProfile(t *testing.T) {
+	m := Manifest{Units: "units.json"}
+	for name, want := range map[string]bool{
+		"profile.json": true,
+		"EXVS.JSON":    true,
+		"units.json":   false,
+		"aliases.csv":  false,
+	} {
+		if got := IsProfile(m, name); got != want {
+			t.Errorf("IsProfile(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package diagnostics collects the effective configuration and the state of
+// the running game into a plain-text block that can be pasted into support
+// requests.
+package diagnostics
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"ms-changer/gameproc"
+	"ms-changer/memory"
+	"ms-changer/profile"
+)
+
+// Game describes one running client instance as seen through the profile.
+type Game struct {
+	Process    gameproc.Process
+	Build      string
+	ModuleBase uintptr
+	Target     uintptr
+	Value      *int32
+	Err        string // why the chain could not be followed
+}
+
+type Report struct {
+	Version      string
+	SettingsFile string
+	ProfileFile  string
+	Profile      *profile.Profile
+	UnitsFile    string
+	UnitRows     int
+	Games        []Game
+	Engine       string // what the writers are doing
+	Warnings     []string
+}
+
+// Version describes the running binary from its embedded build info:
+// module version, VCS revision and whether the tree was modified.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	v := info.Main.Version
+	if v == "" || v == "(devel)" {
+		v = "devel"
+	}
+	var rev, when string
+	dirty := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+			if len(rev) > 12 {
+				rev = rev[:12]
+			}
+		case "vcs.time":
+			when = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if rev != "" {
+		v += " (" + rev
+		if dirty {
+			v += "+dirty"
+		}
+		if when != "" {
+			v += ", " + when
+		}
+		v += ")"
+	}
+	return v + " " + info.GoVersion + " " + runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// Games follows the unit chain of prof in every running client. It only
+// reads memory.
+func Games(prof *profile.Profile) ([]Game, error) {
+	procs, err := gameproc.List(prof.ProcessMatcher())
+	if err != nil {
+		return nil, err
+	}
+	var games []Game
+	for _, p := range procs {
+		g := Game{Process: p, Build: p.Build()}
+		g.ModuleBase, g.Target, g.Value, err = follow(p, prof)
+		if err != nil {
+			g.Err = err.Error()
+		}
+		games = append(games, g)
+	}
+	return games, nil
+}
+
+func follow(p gameproc.Process, prof *profile.Profile) (uintptr, uintptr, *int32, error) {
+	mem, err := memory.OpenProcess(p.PID)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer mem.Close()
+
+	mod, err := memory.FindModule(mem, prof.ModuleName(p.Exe))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	target, err := memory.Resolve(mem, mod.Base, prof.Unit)
+	if err != nil {
+		return mod.Base, 0, nil, err
+	}
+	v, err := memory.ReadInt32(mem, target)
+	if err != nil {
+		return mod.Base, target, nil, err
+	}
+	return mod.Base, target, &v, nil
+}
+
+// Check adds warnings about the collected state.
+func (r *Report) Check() {
+	if r.UnitRows == 0 {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("no units loaded from %s", r.UnitsFile))
+	}
+	if len(r.Games) == 0 {
+		r.Warnings = append(r.Warnings, "no game client is running")
+	}
+	for _, g := range r.Games {
+		if g.Err != "" {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("PID %d: %s", g.Process.PID, g.Err))
+		}
+	}
+}
+
+// String renders the report as the copy-paste diagnostics block.
+func (r *Report) String() string {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+
+	line("ms-changer diagnostics (%s)", time.Now().Format(time.RFC3339))
+	line("version:  %s", r.Version)
+	if r.SettingsFile != "" {
+		line("settings: %s", r.SettingsFile)
+	}
+	if p := r.Profile; p != nil {
+		module := p.Module
+		if module == "" {
+			module = "(executable)"
+		}
+		line("profile:  %s (%s)", p.Name, r.ProfileFile)
+		line("process:  %s, module %s, retry %s", p.Process, module, time.Duration(p.RetryInterval))
+		line("unit:     %s", formatChain(p.Unit))
+		for _, w := range p.Watches {
+			line("watch:    %s %s apply_when %v", w.Name, formatChain(w.Chain), w.ApplyWhen)
+		}
+	}
+	line("units:    %s (%d rows)", r.UnitsFile, r.UnitRows)
+	for _, g := range r.Games {
+		line("game:     PID %d %s, build %s, started %s", g.Process.PID, g.Process.Exe, g.Build,
+			g.Process.StartTime.Format(time.DateTime))
+		if g.ModuleBase != 0 {
+			line("          module base 0x%X", g.ModuleBase)
+		}
+		if g.Value != nil {
+			line("          unit at 0x%X = %d", g.Target, *g.Value)
+		}
+	}
+	if r.Engine != "" {
+		line("engine:   %s", r.Engine)
+	}
+	if len(r.Warnings) == 0 {
+		line("warnings: none")
+	}
+	for _, w := range r.Warnings {
+		line("warning:  %s", w)
+	}
+	return b.String()
+}
+
+func formatChain(c profile.Chain) string {
+	offsets := make([]string, len(c.Offsets))
+	for i, o := range c.Offsets {
+		offsets[i] = fmt.Sprintf("0x%X", uintptr(o))
+	}
+	return fmt.Sprintf("base 0x%X offsets [%s]", uintptr(c.Base), strings.Join(offsets, " "))
+}
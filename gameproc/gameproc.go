@@ -0,0 +1,69 @@
+// Package gameproc enumerates running game client processes so that a
+// specific instance can be chosen when several are running.
+package gameproc
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+type Process struct {
+	PID         uint32
+	Exe         string
+	Path        string // full path of the executable, empty when unknown
+	StartTime   time.Time
+	CommandLine string
+}
+
+func (p Process) String() string {
+	return fmt.Sprintf("PID %d (started %s)", p.PID, p.StartTime.Format("15:04:05"))
+}
+
+// Find returns the running process with the given PID if it satisfies match.
+func Find(match func(exe string) bool, pid uint32) (Process, error) {
+	procs, err := List(match)
+	if err != nil {
+		return Process{}, err
+	}
+	for _, p := range procs {
+		if p.PID == pid {
+			return p, nil
+		}
+	}
+	return Process{}, fmt.Errorf("PID %d is not a running game client", pid)
+}
+
+// Target returns the process to write to: the one with the given PID, or
+// for 0 the first one found, so that a writer started on "first found"
+// sticks to that client.
+func Target(match func(exe string) bool, pid uint32) (Process, error) {
+	if pid != 0 {
+		return Find(match, pid)
+	}
+	procs, err := List(match)
+	if err != nil {
+		return Process{}, err
+	}
+	if len(procs) == 0 {
+		return Process{}, fmt.Errorf("no game client is running")
+	}
+	return procs[0], nil
+}
+
+// Build identifies the game build the process runs: the executable's file
+// version where the platform records one, otherwise its size and
+// modification time.
+func (p Process) Build() string {
+	if p.Path == "" {
+		return "unknown"
+	}
+	if v, err := fileVersion(p.Path); err == nil {
+		return v
+	}
+	fi, err := os.Stat(p.Path)
+	if err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d bytes, modified %s", fi.Size(), fi.ModTime().Format("2006-01-02 15:04"))
+}
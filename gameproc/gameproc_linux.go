@@ -0,0 +1,107 @@
+//go:build linux
+// +build linux
+
+package gameproc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// List returns every running process whose executable name satisfies match,
+// oldest first. The name is taken from /proc/<pid>/exe, falling back to
+// argv[0] for processes whose executable cannot be read.
+func List(match func(exe string) bool) ([]Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	boot := bootTime()
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.ParseUint(e.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", e.Name())
+
+		cmdline, _ := os.ReadFile(filepath.Join(dir, "cmdline"))
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+
+		exe, path := "", ""
+		if link, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+			path = strings.TrimSuffix(link, " (deleted)")
+			exe = filepath.Base(path)
+		} else if args[0] != "" {
+			exe = filepath.Base(args[0])
+		}
+		if exe == "" || !match(exe) {
+			continue
+		}
+
+		procs = append(procs, Process{
+			PID:         uint32(pid),
+			Exe:         exe,
+			Path:        path,
+			StartTime:   startTime(dir, boot),
+			CommandLine: strings.Join(args, " "),
+		})
+	}
+
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].StartTime.Before(procs[j].StartTime)
+	})
+	return procs, nil
+}
+
+// fileVersion is only available on Windows.
+func fileVersion(path string) (string, error) {
+	return "", fmt.Errorf("no version information")
+}
+
+// bootTime reads the btime line of /proc/stat.
+func bootTime() time.Time {
+	stat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(stat), "\n") {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			if sec, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return time.Unix(sec, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// startTime converts field 22 of /proc/<pid>/stat, in clock ticks since boot.
+// USER_HZ is 100 on every architecture Linux supports today.
+func startTime(dir string, boot time.Time) time.Time {
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil || boot.IsZero() {
+		return time.Time{}
+	}
+	// Skip "pid (comm)" since comm may contain spaces.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return time.Time{}
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	// fields[0] is field 3 (state), so field 22 is fields[19].
+	if len(fields) < 20 {
+		return time.Time{}
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / 100)
+}
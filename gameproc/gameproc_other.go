@@ -0,0 +1,15 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package gameproc
+
+import "fmt"
+
+// List is only implemented on Windows.
+func List(match func(exe string) bool) ([]Process, error) {
+	return nil, fmt.Errorf("process enumeration is not supported on this platform")
+}
+
+func fileVersion(path string) (string, error) {
+	return "", fmt.Errorf("no version information")
+}
@@ -0,0 +1,35 @@
+package gameproc
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestTarget(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		t.Skip("processes are only listed on Linux and Windows")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The test binary stands in for the game client.
+	self := func(name string) bool { return name == filepath.Base(exe) }
+	none := func(string) bool { return false }
+	pid := uint32(os.Getpid())
+
+	if p, err := Target(self, 0); err != nil || p.PID != pid {
+		t.Errorf("Target(0) = %v, %v, want the first client found, PID %d", p, err, pid)
+	}
+	if p, err := Target(self, pid); err != nil || p.PID != pid {
+		t.Errorf("Target(%d) = %v, %v", pid, p, err)
+	}
+	if _, err := Target(none, 0); err == nil {
+		t.Error("Target(0) found a client where none is running")
+	}
+	if _, err := Target(none, pid); err == nil {
+		t.Error("Target accepted a PID that is not a game client")
+	}
+}
@@ -0,0 +1,103 @@
+//go:build windows
+// +build windows
+
+package gameproc
+
+import (
+	"fmt"
+	"sort"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// List returns every running process whose executable name satisfies match,
+// oldest first. Start time and command line are left empty when the process cannot
+// be queried.
+func List(match func(exe string) bool) ([]Process, error) {
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snap)
+
+	var procs []Process
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	err = windows.Process32First(snap, &entry)
+	for err == nil {
+		exe := syscall.UTF16ToString(entry.ExeFile[:])
+		if match(exe) {
+			procs = append(procs, query(entry.ProcessID, exe))
+		}
+		err = windows.Process32Next(snap, &entry)
+	}
+
+	sort.Slice(procs, func(i, j int) bool {
+		return procs[i].StartTime.Before(procs[j].StartTime)
+	})
+	return procs, nil
+}
+
+func query(pid uint32, exe string) Process {
+	p := Process{PID: pid, Exe: exe}
+
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return p
+	}
+	defer windows.CloseHandle(h)
+
+	var created, exited, kernel, user windows.Filetime
+	if windows.GetProcessTimes(h, &created, &exited, &kernel, &user) == nil {
+		p.StartTime = time.Unix(0, created.Nanoseconds())
+	}
+	p.CommandLine = commandLine(h)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if windows.QueryFullProcessImageName(h, 0, &buf[0], &size) == nil {
+		p.Path = windows.UTF16ToString(buf[:size])
+	}
+	return p
+}
+
+// fileVersion reads the file version from the executable's version resource.
+func fileVersion(path string) (string, error) {
+	size, err := windows.GetFileVersionInfoSize(path, nil)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, size)
+	if err := windows.GetFileVersionInfo(path, 0, size, unsafe.Pointer(&data[0])); err != nil {
+		return "", err
+	}
+	var info *windows.VS_FIXEDFILEINFO
+	var n uint32
+	if err := windows.VerQueryValue(unsafe.Pointer(&data[0]), `\`, unsafe.Pointer(&info), &n); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d.%d.%d.%d",
+		info.FileVersionMS>>16, info.FileVersionMS&0xFFFF,
+		info.FileVersionLS>>16, info.FileVersionLS&0xFFFF), nil
+}
+
+func commandLine(h windows.Handle) string {
+	buf := make([]byte, 1024)
+	for {
+		var size uint32
+		err := windows.NtQueryInformationProcess(h, windows.ProcessCommandLineInformation,
+			unsafe.Pointer(&buf[0]), uint32(len(buf)), &size)
+		if err == nil {
+			break
+		}
+		if err == windows.STATUS_INFO_LENGTH_MISMATCH && int(size) > len(buf) {
+			buf = make([]byte, size)
+			continue
+		}
+		return ""
+	}
+	return (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0])).String()
+}
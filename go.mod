@@ -2,7 +2,11 @@ module ms-changer
 
 go 1.24.5
 
-require golang.org/x/sys v0.34.0
+require (
+	github.com/fsnotify/fsnotify v1.7.0
+	golang.org/x/sys v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	fyne.io/fyne/v2 v2.6.1
@@ -10,7 +14,6 @@ require (
 	github.com/BurntSushi/toml v1.4.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fredbi/uri v1.1.0 // indirect
-	github.com/fsnotify/fsnotify v1.7.0 // indirect
 	github.com/fyne-io/gl-js v0.1.0 // indirect
 	github.com/fyne-io/glfw-js v0.2.0 // indirect
 	github.com/fyne-io/image v0.1.1 // indirect
@@ -36,5 +39,4 @@ require (
 	golang.org/x/image v0.24.0 // indirect
 	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,62 @@
+// Package i18n translates the messages of the GUI and the CLIs. Messages
+// are written in English in the code and looked up by their English text
+// in the catalog of the selected language; a message missing from the
+// catalog is shown in English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+type state struct {
+	lang    string
+	catalog map[string]string
+}
+
+var current atomic.Pointer[state]
+
+func init() {
+	current.Store(&state{lang: "en"})
+}
+
+// SetLanguage selects the catalog used by T. English needs no catalog.
+func SetLanguage(lang string) error {
+	if lang == "en" || lang == "" {
+		current.Store(&state{lang: "en"})
+		return nil
+	}
+	data, err := catalogs.ReadFile("catalogs/" + lang + ".json")
+	if err != nil {
+		return fmt.Errorf("no translations for language %q", lang)
+	}
+	var catalog map[string]string
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return fmt.Errorf("catalog %s: %v", lang, err)
+	}
+	current.Store(&state{lang: lang, catalog: catalog})
+	return nil
+}
+
+// Language returns the selected language.
+func Language() string {
+	return current.Load().lang
+}
+
+// T returns the translation of msg, or msg itself when there is none.
+func T(msg string) string {
+	if t, ok := current.Load().catalog[msg]; ok && t != "" {
+		return t
+	}
+	return msg
+}
+
+// Tf translates format and formats it with args.
+func Tf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
@@ -0,0 +1,176 @@
+// Package instance keeps one MS Changer writing to the game at a time. The
+// first process takes a lock file and listens on a local socket; a second
+// one finds the lock taken and sends its command to the first instead,
+// e.g. "write 42" from the CLI to the running GUI. The GUI can take the
+// same commands on a TCP port too.
+package instance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	LockFile   = "ms-changer.lock" // holds the PID of the running instance
+	SocketFile = "ms-changer.sock" // Unix domain socket, also on Windows 10 and later
+)
+
+// Timeout bounds a whole exchange, so a stuck instance cannot hang the
+// sender.
+var Timeout = 10 * time.Second
+
+// ErrRunning is returned by Acquire when another instance holds the lock.
+var ErrRunning = errors.New("another MS Changer is running")
+
+// Request is a command sent to the running instance, one JSON line per
+// connection.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response is the answer to a Request, one JSON line.
+type Response struct {
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Handler runs a command received from another invocation and returns a
+// message for it.
+type Handler func(command string, args []string) (string, error)
+
+// Instance is the running instance: it holds the lock until Close.
+type Instance struct {
+	dir  string
+	lock *os.File
+	ln   net.Listener
+}
+
+// Acquire takes the lock in dir and starts listening for commands. It
+// returns ErrRunning when another process has the lock.
+func Acquire(dir string) (*Instance, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, LockFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	// A socket file left behind by a crash is stale: we hold the lock.
+	path := filepath.Join(dir, SocketFile)
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("listen on %s: %v", path, err)
+	}
+	return &Instance{dir: dir, lock: f, ln: ln}, nil
+}
+
+// Serve answers requests with h until Close. Each connection is handled
+// in its own goroutine.
+func (in *Instance) Serve(h Handler) error {
+	return serveListener(in.ln, h)
+}
+
+func serveListener(ln net.Listener, h Handler) error {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go serve(conn, h)
+	}
+}
+
+func serve(conn net.Conn, h Handler) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(Timeout))
+	var req Request
+	var resp Response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("bad request: %v", err)
+	} else if msg, err := h(req.Command, req.Args); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Message = msg
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// Close stops listening and releases the lock. The lock file stays: a
+// process that opened it meanwhile may already be locking it.
+func (in *Instance) Close() error {
+	err := in.ln.Close()
+	os.Remove(filepath.Join(in.dir, SocketFile))
+	in.lock.Close()
+	return err
+}
+
+// Server answers the same requests on a TCP port of the loopback interface,
+// for tools that cannot reach the socket, such as stream deck plugins.
+type Server struct {
+	ln net.Listener
+}
+
+// ListenTCP listens on port on 127.0.0.1; port 0 picks a free one.
+func ListenTCP(port int) (*Server, error) {
+	ln, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	return &Server{ln: ln}, nil
+}
+
+// Port returns the port the server listens on.
+func (s *Server) Port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// Serve answers requests with h until Close.
+func (s *Server) Serve(h Handler) error {
+	return serveListener(s.ln, h)
+}
+
+func (s *Server) Close() error {
+	return s.ln.Close()
+}
+
+// Send runs a command in the instance running in dir and returns its
+// message. A command the instance refused is returned as an error.
+func Send(dir, command string, args ...string) (string, error) {
+	conn, err := net.DialTimeout("unix", filepath.Join(dir, SocketFile), Timeout)
+	if err != nil {
+		return "", fmt.Errorf("the running instance is not answering: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(Timeout))
+	if err := json.NewEncoder(conn).Encode(Request{command, args}); err != nil {
+		return "", err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", fmt.Errorf("no answer from the running instance: %v", err)
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Message, nil
+}
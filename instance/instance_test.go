@@ -0,0 +1,161 @@
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// start acquires dir and serves h until the test ends.
+func start(t *testing.T, dir string, h Handler) *Instance {
+	t.Helper()
+	in, err := Acquire(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- in.Serve(h) }()
+	t.Cleanup(func() {
+		in.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve = %v after Close", err)
+		}
+	})
+	return in
+}
+
+func echo(command string, args []string) (string, error) {
+	if command == "fail" {
+		return "", errors.New("unknown unit " + strings.Join(args, " "))
+	}
+	return command + ":" + strings.Join(args, ","), nil
+}
+
+func TestAcquire(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "windows" {
+		t.Skip("the lock is only implemented on Linux and Windows")
+	}
+	dir := filepath.Join(t.TempDir(), "settings")
+	start(t, dir, echo)
+
+	if _, err := Acquire(dir); !errors.Is(err, ErrRunning) {
+		t.Errorf("second Acquire = %v, want ErrRunning", err)
+	}
+	// The lock does not keep others from reading the PID.
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lock file holds %q, want the PID %d", got, os.Getpid())
+	}
+}
+
+func TestSend(t *testing.T) {
+	dir := t.TempDir()
+	start(t, dir, echo)
+
+	if msg, err := Send(dir, "write", "42", "RX-78-2"); err != nil || msg != "write:42,RX-78-2" {
+		t.Errorf("Send = %q, %v", msg, err)
+	}
+	if msg, err := Send(dir, "show"); err != nil || msg != "show:" {
+		t.Errorf("Send without args = %q, %v", msg, err)
+	}
+	if _, err := Send(dir, "fail", "999"); err == nil || err.Error() != "unknown unit 999" {
+		t.Errorf("Send of a refused command = %v, want the handler's error", err)
+	}
+}
+
+func TestSendNotRunning(t *testing.T) {
+	if _, err := Send(t.TempDir(), "show"); err == nil {
+		t.Error("Send without a running instance succeeded")
+	}
+}
+
+func TestStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+	// A crashed instance leaves its socket file behind.
+	if err := os.WriteFile(filepath.Join(dir, SocketFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	start(t, dir, echo)
+	if msg, err := Send(dir, "show"); err != nil || msg != "show:" {
+		t.Errorf("Send after replacing a stale socket = %q, %v", msg, err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	dir := t.TempDir()
+	in, err := Acquire(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := in.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SocketFile)); !os.IsNotExist(err) {
+		t.Errorf("socket left after Close: %v", err)
+	}
+	if _, err := Send(dir, "show"); err == nil {
+		t.Error("Send succeeded after Close")
+	}
+
+	// The lock is free again.
+	start(t, dir, echo)
+	if msg, err := Send(dir, "show"); err != nil || msg != "show:" {
+		t.Errorf("Send to the next instance = %q, %v", msg, err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	srv, err := ListenTCP(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- srv.Serve(echo) }()
+
+	// One JSON line each way, as a stream deck plugin would send it.
+	exchange := func(request string) Response {
+		t.Helper()
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(srv.Port())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		fmt.Fprintln(conn, request)
+		var resp Response
+		if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := exchange(`{"command": "write", "args": ["42"]}`); resp.Message != "write:42" || resp.Error != "" {
+		t.Errorf("write: %+v", resp)
+	}
+	if resp := exchange(`{"command": "fail", "args": ["999"]}`); resp.Error != "unknown unit 999" {
+		t.Errorf("refused command: %+v", resp)
+	}
+	if resp := exchange(`GET / HTTP/1.1`); !strings.HasPrefix(resp.Error, "bad request") {
+		t.Errorf("not JSON: %+v", resp)
+	}
+
+	port := srv.Port()
+	srv.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Serve = %v after Close", err)
+	}
+	// The port is free again, e.g. for the server on the new settings.
+	again, err := ListenTCP(port)
+	if err != nil {
+		t.Fatalf("port %d still taken after Close: %v", port, err)
+	}
+	again.Close()
+}
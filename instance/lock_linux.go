@@ -0,0 +1,20 @@
+//go:build linux
+// +build linux
+
+package instance
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive flock on f, which the kernel drops when the
+// process exits.
+func lock(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return ErrRunning
+	}
+	return err
+}
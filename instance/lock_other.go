@@ -0,0 +1,12 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package instance
+
+import "os"
+
+// lock is only implemented on Windows and Linux; elsewhere every process
+// gets the lock.
+func lock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package instance
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockOffset is the byte locked, far past the PID so that other processes
+// can still read it: Windows locks are mandatory.
+const lockOffset = 1 << 30
+
+// lock locks one byte of f beyond its end, which Windows releases when the
+// process exits.
+func lock(f *os.File) error {
+	ol := windows.Overlapped{Offset: lockOffset}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return ErrRunning
+	}
+	return err
+}
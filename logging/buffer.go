@@ -0,0 +1,189 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is a log record kept by a Buffer.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr
+}
+
+// String renders the record for the GUI log viewer.
+func (r Record) String() string {
+	return r.Time.Format("15:04:05") + " " + r.Level.String() + " " + Translate(r.Message) + FormatAttrs(r.Attrs)
+}
+
+// Human renders the record the way HumanHandler prints it.
+func (r Record) Human() string {
+	return Translate(r.Message) + FormatAttrs(r.Attrs)
+}
+
+// Buffer keeps the latest records in memory. The GUI installs its handler
+// as the default logger and feeds it the JSON output of ms-changer-gui-cli,
+// so its log viewer shows both.
+type Buffer struct {
+	mu      sync.Mutex
+	size    int
+	records []Record
+}
+
+// NewBuffer returns a buffer holding up to size records.
+func NewBuffer(size int) *Buffer {
+	return &Buffer{size: size}
+}
+
+// Add appends a record, dropping the oldest when the buffer is full.
+func (b *Buffer) Add(r Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records = append(b.records, r)
+	if len(b.records) > b.size {
+		b.records = append([]Record(nil), b.records[len(b.records)-b.size:]...)
+	}
+}
+
+// Records returns the buffered records at or above min, oldest first.
+func (b *Buffer) Records(min slog.Level) []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []Record
+	for _, r := range b.records {
+		if r.Level >= min {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// AddJSON parses a line written by the JSON handler and adds it with the
+// extra attributes. Lines that are not JSON records are kept as errors, so
+// a crash message from a child process is not lost. The parsed record is
+// returned.
+func (b *Buffer) AddJSON(line string, extra ...slog.Attr) Record {
+	r := Record{Time: time.Now(), Level: slog.LevelError, Message: strings.TrimSpace(line)}
+
+	var fields map[string]any
+	if json.Unmarshal([]byte(line), &fields) == nil {
+		r.Message, _ = fields["msg"].(string)
+		if t, ok := fields["time"].(string); ok {
+			r.Time, _ = time.Parse(time.RFC3339Nano, t)
+		}
+		if lvl, ok := fields["level"].(string); ok {
+			r.Level.UnmarshalText([]byte(lvl))
+		}
+		delete(fields, "msg")
+		delete(fields, "time")
+		delete(fields, "level")
+
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			r.Attrs = append(r.Attrs, slog.Any(k, fields[k]))
+		}
+	}
+	r.Attrs = append(extra, r.Attrs...)
+	b.Add(r)
+	return r
+}
+
+// Handler returns a slog handler that adds records at or above level to b.
+func (b *Buffer) Handler(level slog.Leveler) slog.Handler {
+	return &bufferHandler{buf: b, level: level}
+}
+
+type bufferHandler struct {
+	buf   *Buffer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func (h *bufferHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *bufferHandler) Handle(_ context.Context, r slog.Record) error {
+	rec := Record{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: append([]slog.Attr(nil), h.attrs...)}
+	r.Attrs(func(a slog.Attr) bool {
+		rec.Attrs = append(rec.Attrs, a)
+		return true
+	})
+	h.buf.Add(rec)
+	return nil
+}
+
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &h2
+}
+
+func (h *bufferHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// Tee returns a handler passing every record to all of handlers.
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return teeHandler(handlers)
+}
+
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// Attr returns the value of the record's attribute key as a string, or ""
+// when it has none.
+func (r Record) Attr(key string) string {
+	for _, a := range r.Attrs {
+		if a.Key == key {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
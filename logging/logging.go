@@ -0,0 +1,163 @@
+// Package logging configures log/slog for the ms-changer tools. Besides the
+// standard text and JSON handlers it provides the emoji-friendly "human"
+// format the tools have always printed, and an in-memory buffer the GUI's
+// log viewer is fed from.
+package logging
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Translate is applied to messages printed for people (the human format
+// and the GUI). Records written as text or JSON keep the original message
+// so they can be filtered the same way in every language.
+var Translate = func(msg string) string { return msg }
+
+// Options holds the values of the -log-level and -log-format flags.
+type Options struct {
+	Level  string
+	Format string
+}
+
+// RegisterFlags adds -log-level and -log-format to fs.
+func RegisterFlags(fs *flag.FlagSet) *Options {
+	o := &Options{}
+	fs.StringVar(&o.Level, "log-level", "info", "log level: debug, info, warn or error")
+	fs.StringVar(&o.Format, "log-format", "human", "log format: human, text or json")
+	return o
+}
+
+// Setup installs the default slog logger writing to w.
+func (o *Options) Setup(w io.Writer) error {
+	h, err := NewHandler(w, o.Level, o.Format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// NewHandler returns a handler for the given level and format names.
+func NewHandler(w io.Writer, level, format string) (slog.Handler, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "human", "":
+		return NewHumanHandler(w, opts), nil
+	case "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q", format)
+}
+
+// ParseLevel parses a level name such as "debug" or "warn".
+func ParseLevel(name string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return lvl, nil
+}
+
+// HumanHandler prints the message as is, followed by ": <err>" for an "err"
+// attribute and " key=value" for the others. Messages carry their own emoji,
+// so the level is only shown for debug records.
+type HumanHandler struct {
+	w     io.Writer
+	opts  slog.HandlerOptions
+	mu    *sync.Mutex
+	attrs []slog.Attr
+	group string
+}
+
+func NewHumanHandler(w io.Writer, opts *slog.HandlerOptions) *HumanHandler {
+	h := &HumanHandler{w: w, mu: &sync.Mutex{}}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *HumanHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.opts.Level != nil {
+		min = h.opts.Level.Level()
+	}
+	return level >= min
+}
+
+func (h *HumanHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if r.Level < slog.LevelInfo {
+		b.WriteString("🐞 ")
+	}
+	b.WriteString(Translate(r.Message))
+	b.WriteString(FormatAttrs(h.collect(r)))
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *HumanHandler) collect(r slog.Record) []slog.Attr {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+func (h *HumanHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *HumanHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+	h2.group = name
+	return &h2
+}
+
+// FormatAttrs renders attributes the way HumanHandler prints them.
+func FormatAttrs(attrs []slog.Attr) string {
+	var errText string
+	var b strings.Builder
+	for _, a := range attrs {
+		if a.Key == "err" {
+			errText = a.Value.String()
+			continue
+		}
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+	}
+	if errText != "" {
+		return ": " + errText + b.String()
+	}
+	return b.String()
+}
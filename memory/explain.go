@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"fmt"
+
+	"ms-changer/profile"
+)
+
+// Hop is one dereference of a pointer chain as reported by Explain.
+type Hop struct {
+	Step    int          `json:"step"`
+	Addr    profile.Addr `json:"addr"`    // address the pointer was read from
+	Pointer profile.Addr `json:"pointer"` // raw value read at Addr
+	Offset  profile.Addr `json:"offset"`
+	Next    profile.Addr `json:"next"` // Pointer + Offset
+	// Valid is set when Pointer lands in committed, readable memory.
+	Valid  bool   `json:"valid"`
+	Module string `json:"module,omitempty"` // module containing Pointer
+	Error  string `json:"error,omitempty"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// Explanation is the hop-by-hop result of following a chain.
+type Explanation struct {
+	Base     profile.Addr `json:"base"` // module base + chain base
+	Hops     []Hop        `json:"hops"`
+	Target   profile.Addr `json:"target,omitempty"`
+	Writable bool         `json:"writable"`
+	Value    *int32       `json:"value,omitempty"`
+	Hint     string       `json:"hint,omitempty"`
+}
+
+// OK reports whether the chain resolved to a writable target.
+func (e *Explanation) OK() bool {
+	return e.Target != 0 && e.Writable
+}
+
+// Explain follows chain like Resolve but keeps going as far as it can,
+// validating each pointer against the process's memory map and guessing
+// which offset went stale when it breaks.
+func Explain(p Process, moduleBase uintptr, chain profile.Chain) *Explanation {
+	mods, _ := p.Modules()
+	moduleOf := func(addr uintptr) string {
+		for _, m := range mods {
+			if m.Contains(addr) {
+				return m.Name
+			}
+		}
+		return ""
+	}
+
+	addr := moduleBase + uintptr(chain.Base)
+	e := &Explanation{Base: profile.Addr(addr)}
+
+	if r, err := p.Region(addr); err != nil || !r.Committed || !r.Readable {
+		e.Hint = "base address is not readable: base RVA or module is wrong"
+	}
+
+	for i, offset := range chain.Offsets {
+		hop := Hop{Step: i + 1, Addr: profile.Addr(addr), Offset: offset}
+		ptr, err := ReadPointer(p, addr)
+		if err != nil {
+			hop.Error = err.Error()
+			hop.Hint = staleHint(i, chain)
+			e.Hops = append(e.Hops, hop)
+			return e
+		}
+
+		hop.Pointer = profile.Addr(ptr)
+		hop.Next = profile.Addr(ptr + uintptr(offset))
+		hop.Module = moduleOf(ptr)
+		if r, err := p.Region(ptr); err == nil && r.Committed && r.Readable {
+			hop.Valid = true
+		}
+
+		switch {
+		case ptr == 0:
+			hop.Hint = "null pointer: object not created in this scene, or " + staleHint(i, chain)
+		case !hop.Valid:
+			hop.Hint = "points to unmapped memory: " + staleHint(i, chain)
+		case hop.Module != "" && i > 0:
+			hop.Hint = "points into a module image, not the heap: " + staleHint(i, chain)
+		}
+		e.Hops = append(e.Hops, hop)
+		if ptr == 0 || !hop.Valid {
+			return e
+		}
+		addr = ptr + uintptr(offset)
+	}
+
+	e.Target = profile.Addr(addr)
+	if r, err := p.Region(addr); err == nil && r.Committed && r.Writable {
+		e.Writable = true
+	} else {
+		e.Hint = "target is not writable: last offset is likely stale"
+	}
+	if v, err := ReadInt32(p, addr); err == nil {
+		e.Value = &v
+	}
+	return e
+}
+
+// staleHint names the offset that produced the address read at step i.
+func staleHint(i int, chain profile.Chain) string {
+	if i == 0 {
+		return fmt.Sprintf("base RVA 0x%X is likely stale", uintptr(chain.Base))
+	}
+	return fmt.Sprintf("offset 0x%X at step %d is likely stale", uintptr(chain.Offsets[i-1]), i)
+}
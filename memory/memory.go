@@ -0,0 +1,127 @@
+// Package memory gives access to the game client's memory through the
+// Process interface and follows pointer chains on top of it.
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"ms-changer/profile"
+)
+
+// Region is a contiguous range of pages with the same state and protection.
+type Region struct {
+	Base      uintptr `json:"base"`
+	Size      uintptr `json:"size"`
+	Committed bool    `json:"committed"`
+	Readable  bool    `json:"readable"`
+	Writable  bool    `json:"writable"`
+}
+
+func (r Region) Contains(addr uintptr) bool {
+	return addr >= r.Base && addr-r.Base < r.Size
+}
+
+// Module is an executable image loaded into the process.
+type Module struct {
+	Name string  `json:"name"`
+	Base uintptr `json:"base"`
+	Size uintptr `json:"size"`
+}
+
+func (m Module) Contains(addr uintptr) bool {
+	return addr >= m.Base && addr-m.Base < m.Size
+}
+
+// Process is the memory of a game client.
+type Process interface {
+	Read(addr uintptr, buf []byte) error
+	Write(addr uintptr, buf []byte) error
+	// Region describes the region containing addr.
+	Region(addr uintptr) (Region, error)
+	// Regions lists the committed regions of the address space.
+	Regions() ([]Region, error)
+	Modules() ([]Module, error)
+}
+
+// LiveProcess is a Process attached to a running game client.
+type LiveProcess interface {
+	Process
+	Close() error
+	// Exited reports whether the process has terminated.
+	Exited() bool
+}
+
+// PointerSize is the pointer width of the 64-bit game client.
+const PointerSize = 8
+
+func ReadPointer(p Process, addr uintptr) (uintptr, error) {
+	var buf [PointerSize]byte
+	if err := p.Read(addr, buf[:]); err != nil {
+		return 0, err
+	}
+	return uintptr(binary.LittleEndian.Uint64(buf[:])), nil
+}
+
+func ReadInt32(p Process, addr uintptr) (int32, error) {
+	var buf [4]byte
+	if err := p.Read(addr, buf[:]); err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(buf[:])), nil
+}
+
+func WriteInt32(p Process, addr uintptr, value int32) error {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(value))
+	return p.Write(addr, buf[:])
+}
+
+// FindModule returns the module with the given name (case-insensitive).
+func FindModule(p Process, name string) (Module, error) {
+	mods, err := p.Modules()
+	if err != nil {
+		return Module{}, err
+	}
+	for _, m := range mods {
+		if strings.EqualFold(m.Name, name) {
+			slog.Debug("module found", "module", m.Name, "base", fmt.Sprintf("0x%X", m.Base))
+			return m, nil
+		}
+	}
+	return Module{}, fmt.Errorf("Module %s not found", name)
+}
+
+// Resolve follows chain from moduleBase and returns the final address.
+func Resolve(p Process, moduleBase uintptr, chain profile.Chain) (uintptr, error) {
+	addr := moduleBase + uintptr(chain.Base)
+	for i, offset := range chain.Offsets {
+		next, err := ReadPointer(p, addr)
+		if err != nil {
+			return 0, fmt.Errorf("Failed to resolve pointer at step %d (0x%X): %v", i+1, addr, err)
+		}
+		slog.Debug("chain hop", "step", i+1, "read", fmt.Sprintf("0x%X", addr),
+			"pointer", fmt.Sprintf("0x%X", next), "offset", fmt.Sprintf("0x%X", uintptr(offset)))
+		addr = next + uintptr(offset)
+	}
+	return addr, nil
+}
+
+// ReadWatches reads the current value of every watch. Watch chains are
+// resolved on every call since they may move between scenes; watches that
+// fail to resolve or read are left out.
+func ReadWatches(p Process, moduleBase uintptr, watches []profile.Watch) map[string]int32 {
+	values := make(map[string]int32)
+	for _, w := range watches {
+		addr, err := Resolve(p, moduleBase, w.Chain)
+		if err != nil {
+			continue
+		}
+		if v, err := ReadInt32(p, addr); err == nil {
+			values[w.Name] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	"ms-changer/profile"
+)
+
+func TestReadWatches(t *testing.T) {
+	f := newGame()
+	if err := WriteInt32(f, heapB+0x50, 3); err != nil {
+		t.Fatal(err)
+	}
+	watches := []profile.Watch{
+		{Name: "unit", Chain: chain(0x100, 0x18, 0x40)},
+		{Name: "scene", Chain: chain(0x100, 0x18, 0x50), ApplyWhen: []int32{3}},
+		{Name: "broken", Chain: chain(0x200, 0x18)},     // null pointer
+		{Name: "unmapped", Chain: chain(0x100, 0x1000)}, // past the region
+	}
+	got := ReadWatches(f, moduleBase, watches)
+	want := map[string]int32{"unit": unitValue, "scene": 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadWatches = %v, want %v", got, want)
+	}
+	if got := ReadWatches(f, moduleBase, nil); len(got) != 0 {
+		t.Errorf("ReadWatches without watches = %v", got)
+	}
+}
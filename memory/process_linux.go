@@ -0,0 +1,157 @@
+//go:build linux
+// +build linux
+
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LinuxProcess is a Process backed by /proc/<pid>/mem and /proc/<pid>/maps.
+// It needs ptrace access to the target, i.e. the same user with
+// kernel.yama.ptrace_scope=0, or root.
+type LinuxProcess struct {
+	PID uint32
+	mem *os.File
+}
+
+// OpenProcess opens pid for reading, writing and querying its memory map.
+func OpenProcess(pid uint32) (LiveProcess, error) {
+	f, err := os.OpenFile(fmt.Sprintf("/proc/%d/mem", pid), os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open /proc/%d/mem failed: %v", pid, err)
+	}
+	return &LinuxProcess{PID: pid, mem: f}, nil
+}
+
+func (p *LinuxProcess) Close() error {
+	return p.mem.Close()
+}
+
+func (p *LinuxProcess) Read(addr uintptr, buf []byte) error {
+	if _, err := p.mem.ReadAt(buf, int64(addr)); err != nil {
+		return fmt.Errorf("read at 0x%X: %v", addr, err)
+	}
+	return nil
+}
+
+func (p *LinuxProcess) Write(addr uintptr, buf []byte) error {
+	if _, err := p.mem.WriteAt(buf, int64(addr)); err != nil {
+		return fmt.Errorf("write at 0x%X: %v", addr, err)
+	}
+	return nil
+}
+
+// mapping is one line of /proc/<pid>/maps.
+type mapping struct {
+	Region
+	path string
+}
+
+func (p *LinuxProcess) maps() ([]mapping, error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/maps", p.PID))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var maps []mapping
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// 55d0c2a00000-55d0c2a21000 r-xp 00000000 08:01 1234  /usr/bin/game
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		lo, hi, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			continue
+		}
+		start, err1 := strconv.ParseUint(lo, 16, 64)
+		end, err2 := strconv.ParseUint(hi, 16, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		m := mapping{Region: Region{
+			Base:      uintptr(start),
+			Size:      uintptr(end - start),
+			Committed: true,
+			Readable:  fields[1][0] == 'r',
+			Writable:  fields[1][1] == 'w',
+		}}
+		if len(fields) >= 6 {
+			m.path = strings.Join(fields[5:], " ")
+		}
+		maps = append(maps, m)
+	}
+	return maps, sc.Err()
+}
+
+func (p *LinuxProcess) Region(addr uintptr) (Region, error) {
+	maps, err := p.maps()
+	if err != nil {
+		return Region{}, err
+	}
+	for _, m := range maps {
+		if m.Contains(addr) {
+			return m.Region, nil
+		}
+	}
+	return Region{}, fmt.Errorf("0x%X is not mapped", addr)
+}
+
+func (p *LinuxProcess) Regions() ([]Region, error) {
+	maps, err := p.maps()
+	if err != nil {
+		return nil, err
+	}
+	var regions []Region
+	for _, m := range maps {
+		// The vsyscall page is listed but cannot be read through /proc/<pid>/mem.
+		if m.path != "[vsyscall]" {
+			regions = append(regions, m.Region)
+		}
+	}
+	return regions, nil
+}
+
+// Modules groups the file-backed mappings by file; the module spans from its
+// first to its last mapping.
+func (p *LinuxProcess) Modules() ([]Module, error) {
+	maps, err := p.maps()
+	if err != nil {
+		return nil, err
+	}
+	var mods []Module
+	index := make(map[string]int)
+	for _, m := range maps {
+		if !strings.HasPrefix(m.path, "/") {
+			continue
+		}
+		name := filepath.Base(strings.TrimSuffix(m.path, " (deleted)"))
+		i, ok := index[m.path]
+		if !ok {
+			index[m.path] = len(mods)
+			mods = append(mods, Module{Name: name, Base: m.Base, Size: m.Size})
+			continue
+		}
+		mods[i].Size = m.Base + m.Size - mods[i].Base
+	}
+	return mods, nil
+}
+
+// Exited reports whether the process is gone or a zombie.
+func (p *LinuxProcess) Exited() bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", p.PID))
+	if err != nil {
+		return true
+	}
+	// The state follows the parenthesised command name.
+	i := strings.LastIndexByte(string(stat), ')')
+	return i < 0 || i+2 >= len(stat) || stat[i+2] == 'Z' || stat[i+2] == 'X'
+}
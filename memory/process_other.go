@@ -0,0 +1,11 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package memory
+
+import "fmt"
+
+// OpenProcess is only implemented on Windows; elsewhere work on a Snapshot.
+func OpenProcess(pid uint32) (LiveProcess, error) {
+	return nil, fmt.Errorf("attaching to a live process is not supported on this platform, use a snapshot")
+}
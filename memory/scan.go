@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sort"
+
+	"ms-changer/profile"
+)
+
+// ScanOptions limits a pointer scan.
+type ScanOptions struct {
+	MaxDepth   int     // maximum number of offsets in a chain
+	MaxOffset  uintptr // maximum offset added after each dereference
+	MaxResults int     // stop after this many chains
+}
+
+// pointerRef is an aligned location whose value points into readable memory.
+type pointerRef struct {
+	at    uintptr
+	value uintptr
+}
+
+// ScanPointers searches for pointer chains that start in the static image of
+// module and end at target. It works backwards from target: every readable
+// location holding a value within MaxOffset below the current address is a
+// possible previous hop. Results are ranked shortest chain and smallest
+// offsets first.
+func ScanPointers(p Process, module Module, target uintptr, opt ScanOptions) ([]profile.Chain, error) {
+	refs, err := pointerMap(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// paths returns up to MaxResults chains from the static image of module
+	// to addr with at most left offsets. They are memoized by address and
+	// levels left, so a heap object reached through many pointers is only
+	// searched once, and every way of reaching it gets all of its chains.
+	type node struct {
+		addr uintptr
+		left int
+	}
+	memo := make(map[node][]profile.Chain)
+	var paths func(addr uintptr, left int) []profile.Chain
+	paths = func(addr uintptr, left int) []profile.Chain {
+		if chains, ok := memo[node{addr, left}]; ok {
+			return chains
+		}
+		var chains []profile.Chain
+		lowest := uintptr(0)
+		if addr > opt.MaxOffset {
+			lowest = addr - opt.MaxOffset
+		}
+		i := sort.Search(len(refs), func(i int) bool { return refs[i].value >= lowest })
+		for ; i < len(refs) && refs[i].value <= addr && len(chains) < opt.MaxResults; i++ {
+			ref := refs[i]
+			offset := profile.Addr(addr - ref.value)
+			if module.Contains(ref.at) {
+				chains = append(chains, profile.Chain{Base: profile.Addr(ref.at - module.Base), Offsets: []profile.Addr{offset}})
+				continue
+			}
+			if left > 1 {
+				for _, c := range paths(ref.at, left-1) {
+					offsets := append(slices.Clone(c.Offsets), offset)
+					chains = append(chains, profile.Chain{Base: c.Base, Offsets: offsets})
+					if len(chains) >= opt.MaxResults {
+						break
+					}
+				}
+			}
+		}
+		memo[node{addr, left}] = chains
+		return chains
+	}
+	var results []profile.Chain
+	if opt.MaxDepth > 0 && opt.MaxResults > 0 {
+		results = paths(target, opt.MaxDepth)
+	}
+
+	RankChains(results)
+	return results, nil
+}
+
+// RankChains orders chains shortest first, then by the sum of their offsets.
+func RankChains(chains []profile.Chain) {
+	sum := func(c profile.Chain) (n uintptr) {
+		for _, o := range c.Offsets {
+			n += uintptr(o)
+		}
+		return n
+	}
+	sort.SliceStable(chains, func(i, j int) bool {
+		if len(chains[i].Offsets) != len(chains[j].Offsets) {
+			return len(chains[i].Offsets) < len(chains[j].Offsets)
+		}
+		return sum(chains[i]) < sum(chains[j])
+	})
+}
+
+// ValidateChains keeps the chains that still resolve to an address holding
+// want, e.g. after the game has been restarted.
+func ValidateChains(p Process, moduleBase uintptr, chains []profile.Chain, want int32) []profile.Chain {
+	var valid []profile.Chain
+	for _, c := range chains {
+		addr, err := Resolve(p, moduleBase, c)
+		if err != nil {
+			continue
+		}
+		if v, err := ReadInt32(p, addr); err == nil && v == want {
+			valid = append(valid, c)
+		}
+	}
+	return valid
+}
+
+// pointerMap reads every committed readable region and collects the aligned
+// values that point into readable memory, sorted by value.
+func pointerMap(p Process) ([]pointerRef, error) {
+	regions, err := p.Regions()
+	if err != nil {
+		return nil, err
+	}
+	var readable []Region
+	for _, r := range regions {
+		if r.Committed && r.Readable {
+			readable = append(readable, r)
+		}
+	}
+	sort.Slice(readable, func(i, j int) bool { return readable[i].Base < readable[j].Base })
+
+	isReadable := func(addr uintptr) bool {
+		i := sort.Search(len(readable), func(i int) bool { return readable[i].Base+readable[i].Size > addr })
+		return i < len(readable) && readable[i].Contains(addr)
+	}
+
+	const chunk = 1 << 20
+	var refs []pointerRef
+	buf := make([]byte, chunk)
+	for _, r := range readable {
+		for off := uintptr(0); off < r.Size; off += chunk {
+			n := r.Size - off
+			if n > chunk {
+				n = chunk
+			}
+			if err := p.Read(r.Base+off, buf[:n]); err != nil {
+				// Pages can be released while we scan; skip them.
+				continue
+			}
+			for i := uintptr(0); i+PointerSize <= n; i += PointerSize {
+				v := uintptr(binary.LittleEndian.Uint64(buf[i:]))
+				if v != 0 && isReadable(v) {
+					refs = append(refs, pointerRef{at: r.Base + off + i, value: v})
+				}
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no pointers found in %d readable regions", len(readable))
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].value < refs[j].value })
+	return refs, nil
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"ms-changer/profile"
+)
+
+// fakeProcess is memory made of a few regions, for testing the scanner.
+type fakeProcess struct {
+	regions map[uintptr][]byte // base -> contents
+}
+
+func newFakeProcess() *fakeProcess {
+	return &fakeProcess{regions: map[uintptr][]byte{}}
+}
+
+func (f *fakeProcess) mapRegion(base uintptr, size int) {
+	f.regions[base] = make([]byte, size)
+}
+
+func (f *fakeProcess) find(addr uintptr, n int) ([]byte, error) {
+	for base, data := range f.regions {
+		if addr >= base && addr+uintptr(n) <= base+uintptr(len(data)) {
+			return data[addr-base : addr-base+uintptr(n)], nil
+		}
+	}
+	return nil, fmt.Errorf("0x%X is not mapped", addr)
+}
+
+func (f *fakeProcess) Read(addr uintptr, buf []byte) error {
+	data, err := f.find(addr, len(buf))
+	if err == nil {
+		copy(buf, data)
+	}
+	return err
+}
+
+func (f *fakeProcess) Write(addr uintptr, buf []byte) error {
+	data, err := f.find(addr, len(buf))
+	if err == nil {
+		copy(data, buf)
+	}
+	return err
+}
+
+func (f *fakeProcess) Region(addr uintptr) (Region, error) {
+	for _, r := range f.regionList() {
+		if r.Contains(addr) {
+			return r, nil
+		}
+	}
+	return Region{}, fmt.Errorf("0x%X is not mapped", addr)
+}
+
+func (f *fakeProcess) Regions() ([]Region, error) {
+	return f.regionList(), nil
+}
+
+func (f *fakeProcess) regionList() []Region {
+	var regions []Region
+	for base, data := range f.regions {
+		regions = append(regions, Region{Base: base, Size: uintptr(len(data)), Committed: true, Readable: true, Writable: true})
+	}
+	return regions
+}
+
+func (f *fakeProcess) Modules() ([]Module, error) {
+	return []Module{{Name: "game.exe", Base: moduleBase, Size: 0x1000}}, nil
+}
+
+func (f *fakeProcess) pointer(at, to uintptr) {
+	var buf [PointerSize]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(to))
+	if err := f.Write(at, buf[:]); err != nil {
+		panic(err)
+	}
+}
+
+const (
+	moduleBase = 0x10000
+	heapA      = 0x20000
+	heapB      = 0x30000
+	target     = heapB + 0x40
+	unitValue  = 1001001
+)
+
+// newGame lays out a static -> heap -> target chain,
+//
+//	module+0x100 -> heapA, heapA+0x18 -> heapB, heapB+0x40 = unit
+//
+// and a second way to the unit through the same pointer at heapA+0x18,
+// reached with a different tail:
+//
+//	heapB+0x8 -> heapB+0x30, heapB+0x30+0x10 = unit
+func newGame() *fakeProcess {
+	f := newFakeProcess()
+	f.mapRegion(moduleBase, 0x1000)
+	f.mapRegion(heapA, 0x100)
+	f.mapRegion(heapB, 0x100)
+	f.pointer(moduleBase+0x100, heapA)
+	f.pointer(heapA+0x18, heapB)
+	f.pointer(heapB+0x8, heapB+0x30)
+	if err := WriteInt32(f, target, unitValue); err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func chain(base profile.Addr, offsets ...profile.Addr) profile.Chain {
+	return profile.Chain{Base: base, Offsets: offsets}
+}
+
+func TestScanPointers(t *testing.T) {
+	f := newGame()
+	mod := Module{Name: "game.exe", Base: moduleBase, Size: 0x1000}
+
+	chains, err := ScanPointers(f, mod, target, ScanOptions{MaxDepth: 4, MaxOffset: 0x100, MaxResults: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []profile.Chain{
+		chain(0x100, 0x18, 0x40),
+		// reaches heapA+0x18 a second time through a different tail
+		chain(0x100, 0x18, 0x8, 0x10),
+	}
+	if !reflect.DeepEqual(chains, want) {
+		t.Errorf("ScanPointers = %v, want %v", chains, want)
+	}
+	for _, c := range chains {
+		addr, err := Resolve(f, moduleBase, c)
+		if err != nil || addr != target {
+			t.Errorf("%v resolves to 0x%X, %v, want 0x%X", c, addr, err, target)
+		}
+	}
+
+	// Too shallow for either chain
+	chains, err = ScanPointers(f, mod, target, ScanOptions{MaxDepth: 1, MaxOffset: 0x100, MaxResults: 100})
+	if err != nil || len(chains) != 0 {
+		t.Errorf("depth 1: ScanPointers = %v, %v, want none", chains, err)
+	}
+	// MaxResults stops the search
+	chains, _ = ScanPointers(f, mod, target, ScanOptions{MaxDepth: 4, MaxOffset: 0x100, MaxResults: 1})
+	if len(chains) != 1 {
+		t.Errorf("MaxResults 1: got %d chains", len(chains))
+	}
+	// Offsets above MaxOffset are not followed
+	chains, _ = ScanPointers(f, mod, target, ScanOptions{MaxDepth: 4, MaxOffset: 0x10, MaxResults: 100})
+	if len(chains) != 0 {
+		t.Errorf("MaxOffset 0x10: got %v, want none", chains)
+	}
+}
+
+func TestRankChains(t *testing.T) {
+	chains := []profile.Chain{
+		chain(0x10, 0x8, 0x8, 0x8),
+		chain(0x20, 0x100, 0x8),
+		chain(0x30, 0x10, 0x8),
+		chain(0x40, 0x500),
+	}
+	RankChains(chains)
+	var bases []profile.Addr
+	for _, c := range chains {
+		bases = append(bases, c.Base)
+	}
+	want := []profile.Addr{0x40, 0x30, 0x20, 0x10}
+	if !reflect.DeepEqual(bases, want) {
+		t.Errorf("RankChains order = %v, want %v", bases, want)
+	}
+}
+
+func TestValidateChains(t *testing.T) {
+	candidates := []profile.Chain{
+		chain(0x100, 0x18, 0x40),
+		chain(0x100, 0x18, 0x8, 0x10),
+		chain(0x200, 0x0), // never resolves
+	}
+
+	// After a restart heapA+0x18 still leads to the unit object, but
+	// heapB+0x8 points elsewhere.
+	f := newGame()
+	f.pointer(heapB+0x8, heapA)
+	got := ValidateChains(f, moduleBase, candidates, unitValue)
+	want := []profile.Chain{chain(0x100, 0x18, 0x40)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateChains = %v, want %v", got, want)
+	}
+
+	// In another scene the unit holds a different value: nothing survives.
+	WriteInt32(f, target, 42)
+	if got := ValidateChains(f, moduleBase, candidates, unitValue); len(got) != 0 {
+		t.Errorf("wrong value: ValidateChains = %v, want none", got)
+	}
+}
+
+func TestPointerMap(t *testing.T) {
+	f := newGame()
+	refs, err := pointerMap(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sort.SliceIsSorted(refs, func(i, j int) bool { return refs[i].value < refs[j].value }) {
+		t.Error("pointerMap is not sorted by value")
+	}
+	// Only values pointing into mapped memory count; the unit value does not.
+	want := []pointerRef{{moduleBase + 0x100, heapA}, {heapA + 0x18, heapB}, {heapB + 0x8, heapB + 0x30}}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("pointerMap = %v, want %v", refs, want)
+	}
+}
@@ -0,0 +1,220 @@
+package memory
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"ms-changer/profile"
+)
+
+// Block is a captured range of memory.
+type Block struct {
+	Addr uintptr `json:"addr"`
+	Data []byte  `json:"data"`
+}
+
+func (b Block) end() uintptr {
+	return b.Addr + uintptr(len(b.Data))
+}
+
+// Snapshot is a portable capture of the memory a profile's chains touch. It
+// implements Process, so chains can be resolved and explained offline; writes
+// only change the in-memory copy.
+type Snapshot struct {
+	Version    int       `json:"version"`
+	Captured   time.Time `json:"captured"`
+	PID        uint32    `json:"pid"`
+	Process    string    `json:"process"`
+	Profile    string    `json:"profile"`
+	ModuleList []Module  `json:"modules"`
+	// RegionList holds the memory map entries of every captured address.
+	RegionList []Region `json:"regions"`
+	Blocks     []Block  `json:"blocks"`
+}
+
+const snapshotVersion = 1
+
+// Capture follows every chain from moduleBase and records each address it
+// reads, plus context bytes on either side clipped to the containing region.
+// Chains that break are captured up to the failing hop.
+func Capture(p Process, moduleBase uintptr, chains []profile.Chain, context uintptr) (*Snapshot, error) {
+	mods, err := p.Modules()
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{Version: snapshotVersion, Captured: time.Now(), ModuleList: mods}
+
+	seen := make(map[uintptr]bool)
+	capture := func(addr uintptr) {
+		r, err := p.Region(addr)
+		if err != nil || !r.Committed || !r.Readable {
+			return
+		}
+		if !seen[r.Base] {
+			seen[r.Base] = true
+			s.RegionList = append(s.RegionList, r)
+		}
+
+		start, end := r.Base, r.Base+r.Size
+		if addr-start > context {
+			start = addr - context
+		}
+		if end-addr > context+PointerSize {
+			end = addr + context + PointerSize
+		}
+		data := make([]byte, end-start)
+		if p.Read(start, data) != nil {
+			// Fall back to the value itself if the context is not readable.
+			start, data = addr, make([]byte, PointerSize)
+			if p.Read(start, data) != nil {
+				return
+			}
+		}
+		s.Blocks = append(s.Blocks, Block{Addr: start, Data: data})
+	}
+
+	for _, chain := range chains {
+		addr := moduleBase + uintptr(chain.Base)
+		for _, offset := range chain.Offsets {
+			capture(addr)
+			ptr, err := ReadPointer(p, addr)
+			if err != nil || ptr == 0 {
+				break
+			}
+			addr = ptr + uintptr(offset)
+		}
+		capture(addr)
+	}
+	if len(s.Blocks) == 0 {
+		return nil, fmt.Errorf("nothing could be captured, is the base address readable?")
+	}
+
+	s.mergeBlocks()
+	return s, nil
+}
+
+// mergeBlocks sorts the blocks and joins overlapping or adjacent ones.
+func (s *Snapshot) mergeBlocks() {
+	sort.Slice(s.Blocks, func(i, j int) bool { return s.Blocks[i].Addr < s.Blocks[j].Addr })
+	var merged []Block
+	for _, b := range s.Blocks {
+		if n := len(merged); n > 0 && b.Addr <= merged[n-1].end() {
+			last := &merged[n-1]
+			if b.end() > last.end() {
+				last.Data = append(last.Data, b.Data[last.end()-b.Addr:]...)
+			}
+			continue
+		}
+		merged = append(merged, b)
+	}
+	s.Blocks = merged
+}
+
+// Save writes the snapshot as gzip-compressed JSON.
+func (s *Snapshot) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	if err := json.NewEncoder(zw).Encode(s); err != nil {
+		f.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadSnapshot reads a snapshot written by Save. Plain JSON is accepted too,
+// so hand-written fixtures do not need to be compressed.
+func LoadSnapshot(filename string) (*Snapshot, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	s := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("%s: unsupported snapshot version %d", filename, s.Version)
+	}
+	s.mergeBlocks()
+	return s, nil
+}
+
+func (s *Snapshot) block(addr uintptr, n int) (*Block, error) {
+	i := sort.Search(len(s.Blocks), func(i int) bool { return s.Blocks[i].end() > addr })
+	if i < len(s.Blocks) && s.Blocks[i].Addr <= addr && addr+uintptr(n) <= s.Blocks[i].end() {
+		return &s.Blocks[i], nil
+	}
+	return nil, fmt.Errorf("0x%X is not in the snapshot", addr)
+}
+
+func (s *Snapshot) Read(addr uintptr, buf []byte) error {
+	b, err := s.block(addr, len(buf))
+	if err != nil {
+		return err
+	}
+	copy(buf, b.Data[addr-b.Addr:])
+	return nil
+}
+
+func (s *Snapshot) Write(addr uintptr, buf []byte) error {
+	b, err := s.block(addr, len(buf))
+	if err != nil {
+		return err
+	}
+	copy(b.Data[addr-b.Addr:], buf)
+	return nil
+}
+
+func (s *Snapshot) Region(addr uintptr) (Region, error) {
+	for _, r := range s.RegionList {
+		if r.Contains(addr) {
+			return r, nil
+		}
+	}
+	return Region{}, fmt.Errorf("0x%X is not in the snapshot", addr)
+}
+
+// Regions returns the captured blocks, since only those can be read back.
+func (s *Snapshot) Regions() ([]Region, error) {
+	var regions []Region
+	for _, b := range s.Blocks {
+		r, _ := s.Region(b.Addr)
+		regions = append(regions, Region{
+			Base:      b.Addr,
+			Size:      uintptr(len(b.Data)),
+			Committed: true,
+			Readable:  true,
+			Writable:  r.Writable,
+		})
+	}
+	return regions, nil
+}
+
+func (s *Snapshot) Modules() ([]Module, error) {
+	return s.ModuleList, nil
+}
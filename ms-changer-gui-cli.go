@@ -4,155 +4,170 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
-	"strings"
-	"syscall"
 	"time"
-	"unsafe"
 
-	"golang.org/x/sys/windows"
-)
-
-const (
-	processName = "vsac27_Release_CLIENT.exe"
-
-	// Base RVA from CE screenshot
-	baseRVA = uintptr(0x020023B8)
-)
-
-var (
-	offsets = []uintptr{0x4A0, 0x108, 0x440, 0x188, 0x38, 0x534}
+	"ms-changer/auditlog"
+	"ms-changer/gameproc"
+	"ms-changer/i18n"
+	"ms-changer/logging"
+	"ms-changer/memory"
+	"ms-changer/profile"
+	"ms-changer/settings"
+	"ms-changer/unitdb"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("❌ Usage: ms-changer <unitValue>")
+	pidFlag := flag.Uint("pid", 0, "PID of the game instance to write to")
+	profileFlag := flag.String("profile", "profile.json", "pointer profile to load")
+	processFlag := flag.String("process", "", "process name, glob or re:<regexp> (overrides profile)")
+	moduleFlag := flag.String("module", "", "module holding the chain base (overrides profile)")
+	retryFlag := flag.Duration("retry", 0, "interval between attempts to find the game (overrides profile)")
+	nameFlag := flag.String("name", "", "unit name recorded in the audit log")
+	snapshotFlag := flag.String("snapshot", "", "write to a captured snapshot instead of the running game")
+	logOpts := logging.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if err := logOpts.Setup(os.Stdout); err != nil {
+		fmt.Println("❌", err)
+		return
+	}
+	if path, err := settings.Path(); err == nil {
+		if s, err := settings.Load(path); err == nil {
+			i18n.SetLanguage(s.Language)
+		}
+	}
+	logging.Translate = i18n.T
+
+	if flag.NArg() < 1 {
+		slog.Error("❌ Usage: ms-changer [-pid <pid>] [-profile <file>] <unitValue>")
 		return
 	}
-	unitValue, err := strconv.Atoi(os.Args[1])
+	unitValue, err := strconv.Atoi(flag.Arg(0))
 	if err != nil {
-		fmt.Println("❌ Invalid unitValue:", os.Args[1])
+		slog.Error("❌ Invalid unitValue", "value", flag.Arg(0))
 		return
 	}
 
-	fmt.Printf("✅ Writing unitValue: %d\n", unitValue)
+	slog.Debug("writing unit", "value", unitValue, "unit", *nameFlag)
 
-	pid := waitForGame(processName)
-	fmt.Println("🟢 Found PID:", pid)
-
-	handle, err := openProcess(pid)
-	fmt.Printf("🛠 OpenProcess handle: 0x%X\n", handle)
+	prof, err := profile.Load(profilePath(*profileFlag))
 	if err != nil {
-		fmt.Println("❌ openProcess error:", err)
+		slog.Error("❌ Failed to load profile", "err", err)
 		return
 	}
-	defer syscall.CloseHandle(handle)
-
-	moduleBase, err := getModuleBaseAddress(pid, processName)
-	if err != nil {
-		fmt.Println("❌ getModuleBaseAddress failed:", err)
+	if err := prof.Override(*processFlag, *moduleFlag, *retryFlag); err != nil {
+		slog.Error("❌ Invalid profile", "err", err)
 		return
 	}
-	fmt.Printf("🧩 Module base address: 0x%X\n", moduleBase)
-
-	// Pointer chain from CE screenshot
-	addr := moduleBase + baseRVA
-	fmt.Printf("📌 Starting pointer chain from: 0x%X\n", addr)
-	
-	// Follow the pointer chain
-	for i, offset := range offsets {
-		var nextAddr uintptr
-		ret, _, err := syscall.NewLazyDLL("kernel32.dll").NewProc("ReadProcessMemory").Call(
-			uintptr(handle),
-			addr,
-			uintptr(unsafe.Pointer(&nextAddr)),
-			unsafe.Sizeof(nextAddr),
-			0,
-		)
-		if ret == 0 {
-			fmt.Printf("❌ ReadProcessMemory failed at step %d (0x%X): %v\n", i+1, addr, err)
+
+	var mem memory.Process
+	var pid uint32
+	var exe string
+	if *snapshotFlag != "" {
+		snap, err := memory.LoadSnapshot(*snapshotFlag)
+		if err != nil {
+			slog.Error("❌ Failed to attach", "err", err)
 			return
 		}
-		addr = nextAddr + offset
-		fmt.Printf("🔗 Step %d: 0x%X + 0x%X = 0x%X\n", i+1, nextAddr, offset, addr)
-	}
-	
-	target := addr
-	fmt.Printf("✏️ Final target address for writing: 0x%X\n", target)
-
-	ret, _, err := syscall.NewLazyDLL("kernel32.dll").NewProc("WriteProcessMemory").Call(
-		uintptr(handle),
-		target,
-		uintptr(unsafe.Pointer(&unitValue)),
-		unsafe.Sizeof(unitValue),
-		0,
-	)
-	if ret == 0 {
-		fmt.Printf("❌ WriteProcessMemory failed: %v\n", err)
-		return
-	}
-	fmt.Println("✅ Write successful.")
-}
+		mem, pid, exe = snap, snap.PID, snap.Process
+	} else {
+		var proc gameproc.Process
+		if *pidFlag != 0 {
+			proc, err = gameproc.Find(prof.ProcessMatcher(), uint32(*pidFlag))
+			if err != nil {
+				slog.Error("❌ Game instance not found", "err", err)
+				return
+			}
+		} else {
+			proc = waitForGame(prof)
+		}
+		slog.Debug("found game", "pid", proc.PID)
 
-func waitForGame(name string) uint32 {
-	for {
-		pid, err := getProcessID(name)
-		if err == nil {
-			return pid
+		live, err := memory.OpenProcess(proc.PID)
+		if err != nil {
+			slog.Error("❌ Failed to attach", "pid", proc.PID, "err", err)
+			return
 		}
-		time.Sleep(1 * time.Second)
+		defer live.Close()
+		mem, pid, exe = live, proc.PID, proc.Exe
 	}
-}
 
-func getProcessID(name string) (uint32, error) {
-	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	mod, err := memory.FindModule(mem, prof.ModuleName(exe))
 	if err != nil {
-		return 0, err
+		slog.Error("❌ Failed to attach", "pid", pid, "err", err)
+		return
 	}
-	defer windows.CloseHandle(snap)
 
-	var entry windows.ProcessEntry32
-	entry.Size = uint32(unsafe.Sizeof(entry))
-	err = windows.Process32First(snap, &entry)
-	for err == nil {
-		if strings.EqualFold(syscall.UTF16ToString(entry.ExeFile[:]), name) {
-			return entry.ProcessID, nil
+	// The GUI calls us every second, so a held-back write is simply retried
+	// on the next call until the watched state allows it.
+	if prof.Gated() {
+		values := memory.ReadWatches(mem, mod.Base, prof.Watches)
+		slog.Debug("watches", "values", values)
+		if ok, blocking := prof.CanApply(values); !ok {
+			slog.Info("⏸ Queued: waiting for the game to allow writing.", "watch", blocking, "result", "queued")
+			return
 		}
-		err = windows.Process32Next(snap, &entry)
 	}
-	return 0, fmt.Errorf("Process %s not found", name)
-}
 
-func getModuleBaseAddress(pid uint32, moduleName string) (uintptr, error) {
-	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPMODULE|windows.TH32CS_SNAPMODULE32, pid)
+	target, err := memory.Resolve(mem, mod.Base, prof.Unit)
 	if err != nil {
-		return 0, err
+		slog.Error("❌ Failed to resolve unit chain", "err", err)
+		return
 	}
-	defer windows.CloseHandle(snap)
+	slog.Debug("target address", "target", fmt.Sprintf("0x%X", target))
 
-	var me windows.ModuleEntry32
-	me.Size = uint32(unsafe.Sizeof(me))
-	err = windows.Module32First(snap, &me)
-	for err == nil {
-		if strings.EqualFold(syscall.UTF16ToString(me.Module[:]), moduleName) {
-			return uintptr(me.ModBaseAddr), nil
-		}
-		err = windows.Module32Next(snap, &me)
+	value := int32(unitValue)
+	prev, err := memory.ReadInt32(mem, target)
+	if err == nil && prev == value {
+		slog.Info("✅ Already set.", "value", value, "result", "unchanged")
+		return
+	}
+
+	entry := auditlog.Entry{PID: pid, Profile: prof.Name, UnitName: *nameFlag, Value: value, Previous: prev, Result: auditlog.ResultOK}
+	start := time.Now()
+	if err := memory.WriteInt32(mem, target, value); err != nil {
+		entry.Result, entry.Error = auditlog.ResultFailed, err.Error()
+	} else if v, err := memory.ReadInt32(mem, target); err == nil && v != value {
+		entry.Result = auditlog.ResultReverted
+	}
+	entry.LatencyUS = time.Since(start).Microseconds()
+
+	if audit, err := auditlog.OpenDefault(); err == nil {
+		audit.Write(entry)
+	} else {
+		slog.Warn("⚠️ Audit log disabled", "err", err)
+	}
+
+	switch entry.Result {
+	case auditlog.ResultFailed:
+		slog.Error("❌ Write failed", "pid", pid, "result", entry.Result, "err", entry.Error)
+	case auditlog.ResultReverted:
+		slog.Warn("⚠️ Write did not stick", "pid", pid, "value", value, "previous", prev, "result", entry.Result)
+	default:
+		slog.Info("✅ Write successful.", "pid", pid, "value", value, "previous", prev, "latency_us", entry.LatencyUS, "result", entry.Result)
 	}
-	return 0, fmt.Errorf("Module %s not found", moduleName)
 }
 
-func openProcess(pid uint32) (syscall.Handle, error) {
-	handleRaw, _, err := syscall.NewLazyDLL("kernel32.dll").NewProc("OpenProcess").Call(
-		uintptr(0x0010|0x0020|0x0008|0x0400), // VM_READ | VM_WRITE | VM_OPERATION | QUERY_INFORMATION
-		0,
-		uintptr(pid),
-	)
-	handle := syscall.Handle(handleRaw)
-	if handle == 0 || handle == syscall.InvalidHandle {
-		return 0, fmt.Errorf("OpenProcess failed: %v", err)
-	}
-	return handle, nil
+// profilePath finds a pointer profile the way the units file is found: as
+// given, next to the executable, then in the config dir.
+func profilePath(name string) string {
+	if path := unitdb.Find(name); path != "" {
+		return path
+	}
+	return name
+}
+
+func waitForGame(prof *profile.Profile) gameproc.Process {
+	for {
+		procs, err := gameproc.List(prof.ProcessMatcher())
+		if err == nil && len(procs) > 0 {
+			return procs[0]
+		}
+		time.Sleep(time.Duration(prof.RetryInterval))
+	}
 }
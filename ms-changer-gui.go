@@ -3,14 +3,21 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
+	_ "embed"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
-	"unsafe"
 	"time"
 	"sort"
 
@@ -18,42 +25,98 @@ import (
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"golang.org/x/sys/windows"
+
+	"ms-changer/auditlog"
+	"ms-changer/diagnostics"
+	"ms-changer/gameproc"
+	"ms-changer/i18n"
+	"ms-changer/instance"
+	"ms-changer/logging"
+	"ms-changer/profile"
+	"ms-changer/settings"
+	"ms-changer/unitdb"
+	"ms-changer/unitlist"
+	"ms-changer/watch"
 )
 
-type Unit struct {
-	ID    int32
-	Title string
-	MS    string
-	Value int32
-}
+type Unit = unitdb.Unit
+
+// defaultUnits is used when no units file is found next to the working
+// directory, the executable or in the user config dir.
+//
+//go:embed units.csv
+var defaultUnits []byte
 
-const processName = "vsac27_Release_CLIENT.exe"
+//go:embed aliases.csv
+var defaultAliases []byte
+
+//go:embed series.csv
+var defaultSeries []byte
 
 var (
 	allUnits []Unit
-	stopChan chan struct{}
+	unitsSource unitdb.Source // where allUnits was loaded from
+	searchIndex *unitdb.Index
+	series *unitdb.Catalog // tab order, labels and icons
+	unitFilter unitdb.Filter // cost filter chosen next to the search box
+	prof *profile.Profile
+	writers = make(map[uint32]chan bool) // stop channels keyed by PID; true = restore
+	writerUnits = make(map[uint32]string)     // unit each writer writes, for diagnostics
+	selectedPID uint32 // 0 = first found
+	autoPID uint32 // the first client found, 0 = none
 	selectedUnit *Unit
-	searchEntry *widget.Entry
-	accordion *container.AppTabs
+	searchEntry *unitlist.SearchField
+	unitView *unitlist.Table
 	mainTabs *container.AppTabs
 	progressBar *widget.ProgressBarInfinite
-	radioGroups map[string]*widget.RadioGroup
-	currentTabIndex int
+	logs = logging.NewBuffer(1000) // records shown in the Logs tab
+	cfg atomic.Pointer[settings.Settings] // read by the writer goroutines
+	cfgPath string
+	hotkeys []fyne.Shortcut // shortcuts registered from the settings
+	hotkeyRuns = map[string]func(){} // their actions by ShortcutName
 )
 
 func main() {
+	logOpts := logging.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Everything logged here or by ms-changer-gui-cli.exe ends up in the
+	// Logs tab; -log-format still controls what goes to stderr.
+	level, err := logging.ParseLevel(logOpts.Level)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	if h, err := logging.NewHandler(os.Stderr, logOpts.Level, logOpts.Format); err == nil {
+		slog.SetDefault(slog.New(logging.Tee(logs.Handler(level), h)))
+	} else {
+		slog.SetDefault(slog.New(logs.Handler(level)))
+	}
+
 	a := app.New()
 	a.SetIcon(theme.ComputerIcon())
-	w := a.NewWindow("🤖 MS Changer - Mobile Suit Selector")
+	w := a.NewWindow(i18n.T("🤖 MS Changer - Mobile Suit Selector"))
 	w.Resize(fyne.NewSize(900, 700))
 	w.CenterOnScreen()
 
 	statusBind := binding.NewString()
 	status := widget.NewLabelWithData(statusBind)
 	status.Wrapping = fyne.TextWrapWord
+
+	// report logs a record and shows it in the status line.
+	report := func(level slog.Level, msg string, args ...any) {
+		slog.Log(context.Background(), level, msg, args...)
+		r := slog.NewRecord(time.Now(), level, msg, 0)
+		r.Add(args...)
+		var attrs []slog.Attr
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		statusBind.Set(i18n.T(msg) + logging.FormatAttrs(attrs))
+	}
 	
 	// Create progress bar (initially hidden)
 	progressBar = widget.NewProgressBarInfinite()
@@ -62,125 +125,380 @@ func main() {
 	var startButton *widget.Button
 	selectedID := binding.NewString()
 
+	// Load the user's settings, falling back to the defaults
+	cfg.Store(settings.Default())
+	if cfgPath, err = settings.Path(); err != nil {
+		report(slog.LevelWarn, "⚠️ No config directory, settings will not be saved", "err", err)
+	} else if s, err := settings.Load(cfgPath); err != nil {
+		report(slog.LevelError, "❌ Failed to load settings, using defaults", "err", err)
+	} else {
+		cfg.Store(s)
+	}
+
+	if err := i18n.SetLanguage(cfg.Load().Language); err != nil {
+		report(slog.LevelWarn, "⚠️ Using English", "err", err)
+	}
+	logging.Translate = i18n.T
+
+	// Only one MS Changer writes to the game: a second GUI brings the
+	// first one to the front instead.
+	var guard *instance.Instance
+	if cfgPath != "" {
+		dir := filepath.Dir(cfgPath)
+		guard, err = instance.Acquire(dir)
+		if errors.Is(err, instance.ErrRunning) {
+			if _, err := instance.Send(dir, "show"); err == nil {
+				slog.Info("📨 MS Changer is already running, showing it")
+				return
+			}
+			w.SetContent(widget.NewLabel(i18n.T("❌ Another MS Changer is already writing to the game. Close it and try again.")))
+			w.ShowAndRun()
+			return
+		}
+		if err != nil {
+			report(slog.LevelWarn, "⚠️ Single-instance lock unavailable", "err", err)
+		} else {
+			defer guard.Close()
+		}
+	}
+
 	// Check if game process is running
-	if pid, err := getProcessID(processName); err == nil && pid > 0 {
-		statusBind.Set(fmt.Sprintf("✅ Game process found: PID %d", pid))
+	if prof, err = profile.Load(profilePath(cfg.Load().Profile)); err != nil {
+		prof = profile.Default()
+		report(slog.LevelError, "❌ Failed to load profile, using defaults", "file", profilePath(cfg.Load().Profile), "err", err)
+	} else if procs, err := gameproc.List(prof.ProcessMatcher()); err == nil && len(procs) > 0 {
+		report(slog.LevelInfo, "✅ Game process found", "pid", procs[0].PID)
 	} else {
-		statusBind.Set("🕹️ Waiting for game process...")
+		report(slog.LevelInfo, "🕹️ Waiting for game process...")
 	}
 
-	allUnits = loadUnitsFromCSV("units.csv")
+	allUnits, unitsSource = loadUnitsFromCSV(cfg.Load().UnitsFile, cfg.Load().Overrides)
 	if len(allUnits) == 0 {
-		statusBind.Set("❌ Failed to load units.csv")
+		report(slog.LevelError, "❌ Failed to load units", "file", cfg.Load().UnitsFile)
 		return
 	}
+	report(slog.LevelInfo, "📂 Units loaded", "source", unitsSource, "count", len(allUnits))
+	searchIndex = buildSearchIndex(unitsSource.Path, allUnits)
+	series = loadSeries(unitsSource.Path)
 
-	// Create search functionality
-	searchEntry = widget.NewEntry()
-	searchEntry.SetPlaceHolder("🔍 Search Mobile Suit...")
-	searchEntry.OnChanged = func(query string) {
-		updateAccordion(query, selectedID)
+	// notify shows a desktop notification, e.g. while the window is hidden
+	// in the tray.
+	notify := func(title, content string) {
+		a.SendNotification(fyne.NewNotification(i18n.T(title), content))
 	}
+	updateTray := func() {} // set up below when there is a system tray
 
-	// Initialize radio groups map
-	radioGroups = make(map[string]*widget.RadioGroup)
-	
-	// Create accordion with grouped units
-	accordion = container.NewAppTabs()
-	accordion.SetTabLocation(container.TabLocationTop)
-	updateAccordion("", selectedID)
-	
-	// Add tab selection listener
-	accordion.OnChanged = func(tab *container.TabItem) {
-		// Update current tab index for keyboard navigation
-		for i, item := range accordion.Items {
-			if item == tab {
-				currentTabIndex = i
-				break
-			}
+	// Mobile Suit list with a search box; Up/Down/PageUp/PageDown in the
+	// search box move through the list.
+	selectedLabel := widget.NewLabel("")
+	favoriteButton := widget.NewButton("☆", nil)
+	favoriteButton.Importance = widget.LowImportance
+	selectUnit := func(u Unit) {
+		selectedUnit = &u
+		selectedID.Set(strconv.Itoa(int(u.Value)))
+		selectedLabel.SetText(i18n.Tf("🎯 %s / %s (%d)", u.DisplayTitle(i18n.Language()), u.DisplayName(i18n.Language()), u.Value))
+		favoriteButton.SetText(map[bool]string{false: "☆", true: "⭐"}[slices.Contains(cfg.Load().Favorites, u.Value)])
+		updateTray()
+	}
+	// toggleFavorite adds the selected unit to the favorites shown in the
+	// tray menu, or removes it, and saves the settings.
+	toggleFavorite := func() {
+		if selectedUnit == nil {
+			return
 		}
-		
-		// Ensure selection is maintained when switching tabs
-		if tab != nil && radioGroups[tab.Text] != nil {
-			radio := radioGroups[tab.Text]
-			if radio.Selected != "" {
-				// Keep existing selection
-			} else if len(radio.Options) > 0 {
-				// Auto-select first item if nothing selected
-				radio.SetSelected(radio.Options[0])
+		s := *cfg.Load()
+		if i := slices.Index(s.Favorites, selectedUnit.Value); i >= 0 {
+			s.Favorites = slices.Delete(slices.Clone(s.Favorites), i, i+1)
+		} else {
+			s.Favorites = append(slices.Clone(s.Favorites), selectedUnit.Value)
+		}
+		cfg.Store(&s)
+		if cfgPath != "" {
+			if err := s.Save(cfgPath); err != nil {
+				report(slog.LevelError, "❌ Failed to save favorites", "err", err)
 			}
 		}
+		selectUnit(*selectedUnit)
+	}
+	favoriteButton.OnTapped = toggleFavorite
+	unitView = unitlist.New(func() *unitdb.Catalog { return series }, selectUnit)
+	searchEntry = newSearchField()
+	searchEntry.SetPlaceHolder(i18n.T("🔍 Search Mobile Suit..."))
+	searchEntry.OnChanged = func(query string) {
+		updateUnitList(query)
+	}
+	searchEntry.OnKey = func(key fyne.KeyName) bool {
+		switch key {
+		case fyne.KeyDown:
+			unitView.Move(1)
+		case fyne.KeyUp:
+			unitView.Move(-1)
+		case fyne.KeyPageDown:
+			unitView.Move(10)
+		case fyne.KeyPageUp:
+			unitView.Move(-10)
+		default:
+			return false
+		}
+		return true
 	}
+	groupCheck := widget.NewCheck(i18n.T("Group by series"), unitView.SetGrouped)
+	groupCheck.SetChecked(true)
 
-	// Set default selection
+	// Select the first unit to start with
 	if len(allUnits) > 0 {
-		selectedUnit = &allUnits[0]
-		selectedID.Set(strconv.Itoa(int(selectedUnit.Value)))
+		unitView.Selected = allUnits[0].Value
+	}
+	updateUnitList("")
+
+	// Reflect the writer of the selected instance on the start button
+	updateWriterState := func() {
+		if writers[targetPID()] != nil {
+			startButton.Disable()
+			startButton.SetText(i18n.T("⏳ Writing..."))
+		} else {
+			startButton.Enable()
+			startButton.SetText(i18n.T("🚀 Start Writing"))
+		}
+		if len(writers) > 0 {
+			progressBar.Show()
+			progressBar.Start()
+		} else {
+			progressBar.Stop()
+			progressBar.Hide()
+		}
+		updateTray()
 	}
 
-	startButton = widget.NewButton("🚀 Start Writing", func() {
-		if stopChan != nil {
-			statusBind.Set("⚠️ Already running")
+	// recent are the units written last, newest first: from the audit log
+	// at start, then from the writers started.
+	var recent []Unit
+	addRecent := func(u Unit) {
+		recent = slices.DeleteFunc(recent, func(r Unit) bool { return r.Value == u.Value })
+		recent = append([]Unit{u}, recent[:min(len(recent), 9)]...)
+	}
+	auditPath, _ := auditlog.DefaultPath()
+	if entries, err := auditlog.Read(auditPath); err == nil {
+		for _, e := range entries {
+			if i := slices.IndexFunc(allUnits, func(u Unit) bool { return u.Value == e.Value }); i >= 0 && e.Result == auditlog.ResultOK {
+				addRecent(allUnits[i])
+			}
+		}
+	}
+
+	startButton = widget.NewButton(i18n.T("🚀 Start Writing"), func() {
+		// Writers are keyed by the client they write to, so "first found"
+		// and the same client picked by PID cannot both write to it.
+		proc, err := gameproc.Target(prof.ProcessMatcher(), selectedPID)
+		if err != nil {
+			report(slog.LevelWarn, "⚠️ No game instance to write to", "err", err)
+			return
+		}
+		pid := proc.PID
+		if selectedPID == 0 {
+			autoPID = pid
+		}
+		if writers[pid] != nil {
+			report(slog.LevelWarn, "⚠️ Already running", "pid", pid)
+			updateWriterState()
 			return
 		}
 
 		unitValueStr, err := selectedID.Get()
 		if err != nil || unitValueStr == "" || selectedUnit == nil {
-			statusBind.Set("❌ No Mobile Suit selected")
+			report(slog.LevelWarn, "❌ No Mobile Suit selected")
 			return
 		}
 
-		stopChan = make(chan struct{})
+		stop := make(chan bool, 1)
+		writers[pid] = stop
+		writerUnits[pid] = fmt.Sprintf("%s (%s)", selectedUnit.MS, unitValueStr)
+		unit := *selectedUnit
 		unitValue := unitValueStr
-		statusBind.Set(fmt.Sprintf("🚀 Writing started: %s - %s (ID: %s)", selectedUnit.Title, selectedUnit.MS, unitValue))
-		startButton.Disable()
-		startButton.SetText("⏳ Writing...")
-		progressBar.Show()
-		progressBar.Start()
+		addRecent(unit)
+		prefix, pidArgs := fmt.Sprintf("[PID %d] ", pid), []any{"pid", pid}
+		// The arguments are built on every run so changed settings apply to
+		// running writers.
+		cliArgs := func(name, value string) []string {
+			return []string{"-pid", strconv.Itoa(int(pid)), "-log-format", "json", "-log-level", level.String(),
+				"-profile", profilePath(cfg.Load().Profile), "-name", name, value}
+		}
+		failing := false
+		runCLI := func(args []string) (result, previous string) {
+			cmd := exec.Command("./ms-changer-gui-cli.exe", args...)
+			cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				report(slog.LevelError, "❌ CLI error", append(pidArgs, "err", err)...)
+				// once per run of failures, not on every retry
+				if !failing {
+					notify("❌ Write failed", prefix+unit.MS+": "+err.Error())
+				}
+			}
+			failing = err != nil
+			// The CLI logs JSON records; the last one is its outcome.
+			for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+				if line == "" {
+					continue
+				}
+				r := logs.AddJSON(line, slog.Any("writer", pid))
+				if err == nil {
+					statusBind.Set(prefix + r.Human())
+				}
+				result, previous = r.Attr("result"), r.Attr("previous")
+			}
+			return result, previous
+		}
+		report(slog.LevelInfo, "🚀 Writing started", append(pidArgs, "title", unit.Title, "unit", unit.MS, "value", unitValue)...)
+		updateWriterState()
 
 		go func() {
+			original := "" // value before our first write, for restore-on-stop
 			for {
 				select {
-				case <-stopChan:
-					statusBind.Set("⏹ Writing stopped.")
-					stopChan = nil
-					startButton.Enable()
-					startButton.SetText("🚀 Start Writing")
-					progressBar.Stop()
-					progressBar.Hide()
+				case restore := <-stop:
+					if (restore || cfg.Load().RestoreOnStop) && original != "" {
+						runCLI(cliArgs(unit.MS+" (restore)", original))
+						report(slog.LevelInfo, "↩️ Restored previous unit", append(pidArgs, "value", original)...)
+					} else if restore {
+						report(slog.LevelWarn, "⚠️ Nothing to restore", pidArgs...)
+					}
+					report(slog.LevelInfo, "⏹ Writing stopped.", pidArgs...)
 					return
 				default:
-					cmd := exec.Command("./ms-changer-gui-cli.exe", unitValue)
-					cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
-					out, err := cmd.CombinedOutput()
-					if err != nil {
-						statusBind.Set(fmt.Sprintf("❌ CLI error: %v", err))
-					} else {
-						statusBind.Set(string(out))
+					result, previous := runCLI(cliArgs(unit.MS, unitValue))
+					if result == auditlog.ResultOK && original == "" && previous != "0" {
+						original = previous
+					}
+					if cfg.Load().FreezeStrategy == settings.FreezeOnce && (result == auditlog.ResultOK || result == "unchanged") {
+						report(slog.LevelInfo, "✅ Written once, writer finished.", pidArgs...)
+						fyne.Do(func() {
+							if writers[pid] == stop {
+								delete(writers, pid)
+								delete(writerUnits, pid)
+								updateWriterState()
+							}
+						})
+						return
 					}
-					time.Sleep(1 * time.Second)
+					time.Sleep(time.Duration(cfg.Load().WriteInterval))
 				}
 			}
 		}()
 	})
 	startButton.Importance = widget.HighImportance
 
-	stopButton := widget.NewButton("⏹ Stop", func() {
-		if stopChan != nil {
-			close(stopChan)
+	// stopWriter stops the writer of the selected instance. With restore,
+	// or restore_on_stop set, it writes back the unit it replaced.
+	stopWriter := func(restore bool) bool {
+		pid := targetPID()
+		stop := writers[pid]
+		if stop == nil {
+			return false
 		}
-	})
+		stop <- restore
+		delete(writers, pid)
+		delete(writerUnits, pid)
+		updateWriterState()
+		return true
+	}
+	stopButton := widget.NewButton(i18n.T("⏹ Stop"), func() { stopWriter(false) })
 	stopButton.Importance = widget.MediumImportance
 
 	// Create Mobile Suit selection page
+	allCosts := i18n.T("All costs")
+	costSelect := widget.NewSelect([]string{allCosts, "3000", "2500", "2000", "1500"}, func(c string) {
+		unitFilter.Costs = nil
+		if n, err := strconv.Atoi(c); err == nil {
+			unitFilter.Costs = []int{n}
+		}
+		updateUnitList(searchEntry.Text)
+	})
+	costSelect.SetSelected(allCosts)
+
 	selectorHeader := container.NewVBox(
-		widget.NewRichTextFromMarkdown("## 🤖 Mobile Suit Selection"),
-		searchEntry,
+		widget.NewRichTextFromMarkdown(i18n.T("## 🤖 Mobile Suit Selection")),
+		container.NewBorder(nil, nil, nil, container.NewHBox(groupCheck, costSelect), searchEntry),
+		container.NewBorder(nil, nil, nil, favoriteButton, selectedLabel),
 		widget.NewSeparator(),
+		unitView.Header(),
 	)
 
-	selectorContent := container.NewVScroll(accordion)
-	selectorContent.SetMinSize(fyne.NewSize(850, 350))
+	selectorContent := unitView.List
+
+	// Game instance picker for machines running more than one client
+	instancePIDs := map[string]uint32{}
+	instanceSelect := widget.NewSelect(nil, func(label string) {
+		selectedPID = instancePIDs[label]
+		updateWriterState()
+	})
+	refreshInstances := func() {
+		autoLabel := i18n.T("🎮 Auto (first found)")
+		instancePIDs = map[string]uint32{autoLabel: 0}
+		options := []string{autoLabel}
+		procs, _ := gameproc.List(prof.ProcessMatcher())
+		autoPID = 0
+		if len(procs) > 0 {
+			autoPID = procs[0].PID
+		}
+		for _, p := range procs {
+			label := fmt.Sprintf("🖥 %s", p)
+			instancePIDs[label] = p.PID
+			options = append(options, label)
+		}
+		instanceSelect.Options = options
+		selected := autoLabel
+		for label, pid := range instancePIDs {
+			if pid == selectedPID {
+				selected = label
+			}
+		}
+		instanceSelect.SetSelected(selected)
+	}
+	refreshInstances()
+
+	// Watch for game clients starting and exiting: notify and keep the
+	// instance list current.
+	go func() {
+		known := map[uint32]bool{}
+		first := true
+		for range time.Tick(2 * time.Second) {
+			var match func(string) bool
+			fyne.DoAndWait(func() { match = prof.ProcessMatcher() })
+			procs, err := gameproc.List(match)
+			if err != nil {
+				continue
+			}
+			running := map[uint32]bool{}
+			changed := false
+			for _, p := range procs {
+				running[p.PID] = true
+				if !known[p.PID] && !first {
+					changed = true
+					slog.Info("🔗 Game attached", "pid", p.PID)
+					notify("🔗 Game attached", p.String())
+				}
+			}
+			for pid := range known {
+				if !running[pid] {
+					changed = true
+					slog.Info("🔌 Game detached", "pid", pid)
+					notify("🔌 Game detached", fmt.Sprintf("PID %d", pid))
+				}
+			}
+			known, first = running, false
+			if changed {
+				fyne.Do(refreshInstances)
+			}
+		}
+	}()
+
+	instanceRow := container.NewBorder(
+		nil, nil,
+		widget.NewLabel(i18n.T("Instance:")),
+		widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), refreshInstances),
+		instanceSelect,
+	)
 
 	buttonContainer := container.NewGridWithColumns(2,
 		startButton,
@@ -198,6 +516,7 @@ func main() {
 
 	selectorFooter := container.NewVBox(
 		widget.NewSeparator(),
+		instanceRow,
 		buttonContainer,
 		statusContainer,
 	)
@@ -212,257 +531,879 @@ func main() {
 	mainTabs.SetTabLocation(container.TabLocationTop)
 	
 	// Add Mobile Suit selection tab
-	mainTabs.Append(container.NewTabItem("🤖 Mobile Suits", selectorPage))
+	mainTabs.Append(container.NewTabItem(i18n.T("🤖 Mobile Suits"), selectorPage))
 	
-	// Add other pages
-	createAdditionalPages()
+	// Add write log page, refreshed whenever it is shown
+	logTab, refreshLog := createLogPage()
+	mainTabs.Append(logTab)
+	viewerTab, refreshViewer := createLogViewerPage()
+	mainTabs.Append(viewerTab)
+	// Add the generated About and Usage pages
+	aboutTab, usageTab, refreshAbout := createAdditionalPages(w)
+	mainTabs.Append(aboutTab)
+	mainTabs.Append(usageTab)
+	mainTabs.OnSelected = func(tab *container.TabItem) {
+		switch tab {
+		case logTab:
+			refreshLog()
+		case viewerTab:
+			refreshViewer()
+		case aboutTab, usageTab:
+			refreshAbout()
+		}
+	}
 
-	w.SetContent(mainTabs)
+	// reloadUnits loads the units database of s and swaps it in, keeping
+	// the selected unit when its value still exists.
+	reloadUnits := func(s *settings.Settings) error {
+		units, src := loadUnitsFromCSV(s.UnitsFile, s.Overrides)
+		if len(units) == 0 {
+			return fmt.Errorf("no units could be loaded from %s", s.UnitsFile)
+		}
+		allUnits, unitsSource = units, src
+		report(slog.LevelInfo, "📂 Units loaded", "source", unitsSource, "count", len(allUnits))
+		searchIndex = buildSearchIndex(src.Path, units)
+		series = loadSeries(src.Path)
+		if selectedUnit != nil {
+			if i := slices.IndexFunc(allUnits, func(u Unit) bool { return u.Value == selectedUnit.Value }); i >= 0 {
+				selectUnit(allUnits[i]) // may have been renamed
+			} else {
+				selectedUnit = nil
+				selectedID.Set("")
+				selectedLabel.SetText("")
+				unitView.SelectValue(0)
+			}
+		}
+		updateUnitList(searchEntry.Text)
+		return nil
+	}
 
-	w.ShowAndRun()
-}
+	// reload reloads the units database and the pointer profile. Broken
+	// files are reported and the data in use is kept.
+	reload := func() {
+		s := cfg.Load()
+		p, err := profile.Load(profilePath(s.Profile))
+		if err != nil {
+			report(slog.LevelError, "❌ Reload failed, keeping the current data", "err", err)
+			return
+		}
+		if err := reloadUnits(s); err != nil {
+			report(slog.LevelError, "❌ Reload failed, keeping the current data", "err", err)
+			return
+		}
+		prof = p
+		report(slog.LevelInfo, "🔄 Reloaded units and profile", "profile", profilePath(s.Profile))
+	}
 
-func createAdditionalPages() {
-	// About page
-	aboutContent := widget.NewRichTextFromMarkdown(`# 📋 About MS Changer
+	// watchFiles reloads when one of the files changes.
+	var stopWatching context.CancelFunc
+	watchFiles := func() {
+		if stopWatching != nil {
+			stopWatching()
+		}
+		var ctx context.Context
+		ctx, stopWatching = context.WithCancel(context.Background())
+		go watch.Files(ctx, watchedFiles(cfg.Load()), func() { fyne.Do(reload) })
+	}
+	watchFiles()
+
+	// The command palette (Ctrl+K) finds units, series and actions.
+	pickUnit := func(u Unit) {
+		if !slices.ContainsFunc(unitView.Units, func(v Unit) bool { return v.Value == u.Value }) {
+			searchEntry.SetText("")
+		}
+		selectUnit(u)
+		unitView.SelectValue(u.Value)
+		unitView.ScrollToSelected()
+	}
+	pickSeries := func(s unitdb.Series) {
+		if !unitView.ScrollToTitle(s.Title) {
+			searchEntry.SetText("")
+			unitView.ScrollToTitle(s.Title)
+		}
+	}
+	restore := func() {
+		if !stopWriter(true) {
+			report(slog.LevelWarn, "⚠️ Nothing to restore", "pid", targetPID())
+		}
+	}
+	paletteCommands := func() []paletteCommand {
+		commands := []paletteCommand{
+			{"🚀", "Start writing", []string{"write", "freeze"}, startButton.OnTapped},
+			{"⏹", "Stop writing", []string{"halt"}, func() { stopWriter(false) }},
+			{"↩️", "Restore previous unit", []string{"undo", "revert"}, restore},
+			{"🎲", "Random unit", []string{"shuffle", "dice"}, func() {
+				if len(unitView.Units) > 0 {
+					pickUnit(unitView.Units[rand.IntN(len(unitView.Units))])
+				}
+			}},
+			{"🔄", "Reload database", []string{"refresh", "db", "profile"}, reload},
+			{"⭐", "Toggle favorite", []string{"star", "favourite"}, toggleFavorite},
+		}
+		refreshInstances()
+		for _, label := range instanceSelect.Options {
+			commands = append(commands, paletteCommand{"🎮", i18n.Tf("Switch to %s", label), []string{"instance", "pid"}, func() {
+				instanceSelect.SetSelected(label)
+			}})
+		}
+		return commands
+	}
+	hotkeyActions := map[string]func(){
+		"start":   startButton.OnTapped,
+		"stop":    stopButton.OnTapped,
+		"palette": func() { showPalette(w.Canvas(), paletteCommands(), pickUnit, pickSeries) },
+	}
+
+	// Commands from other invocations, e.g. "ms-changer write 42" (a unit
+	// ID or value) while the GUI is running, and from tools on the server
+	// port. Set up below, once the tray exists.
+	var handleCommand instance.Handler
+	// listen moves the command server to port, 0 turning it off. The old
+	// port is only given up once the new one is listening.
+	var server *instance.Server
+	listen := func(port int) error {
+		var srv *instance.Server
+		if port != 0 {
+			var err error
+			if srv, err = instance.ListenTCP(port); err != nil {
+				return err
+			}
+			go srv.Serve(handleCommand)
+			report(slog.LevelInfo, "📡 Listening for commands", "addr", fmt.Sprintf("127.0.0.1:%d", port))
+		}
+		if server != nil {
+			server.Close()
+		}
+		server = srv
+		return nil
+	}
 
-**MS Changer** is a utility for modifying the in-game Mobile Suit selection of a Windows-based arcade client via memory editing.
+	// applySettings validates new settings and puts them into effect.
+	// Running writers pick up the profile, interval and strategy on their
+	// next run.
+	applySettings := func(s *settings.Settings) error {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		p, err := profile.Load(profilePath(s.Profile))
+		if err != nil {
+			return err
+		}
+		old := cfg.Load()
+		if s.UnitsFile != old.UnitsFile || !slices.Equal(s.Overrides, old.Overrides) {
+			if err := reloadUnits(s); err != nil {
+				return err
+			}
+		}
+		if s.ServerPort != old.ServerPort {
+			if err := listen(s.ServerPort); err != nil {
+				return err
+			}
+		}
+		prof = p
+		if s.Language != old.Language {
+			i18n.SetLanguage(s.Language)
+			updateUnitList(searchEntry.Text)
+		}
+		cfg.Store(s)
+		if s.UnitsFile != old.UnitsFile || !slices.Equal(s.Overrides, old.Overrides) || s.Profile != old.Profile {
+			watchFiles()
+		}
+		registerHotkeys(w.Canvas(), s, hotkeyActions)
+		return nil
+	}
+	registerHotkeys(w.Canvas(), cfg.Load(), hotkeyActions)
+	mainTabs.Append(createSettingsPage(applySettings, report))
+
+	// The tray menu shows the current unit, switches to a favorite or
+	// recent unit (restarting a running writer with it) and controls the
+	// writer; closing the window keeps MS Changer running there.
+	showWindow := func() {
+		w.Show()
+		w.RequestFocus()
+	}
+	switchUnit := func(u Unit) {
+		pickUnit(u)
+		if stopWriter(false) {
+			startButton.OnTapped()
+		}
+	}
+	if desk, ok := a.(desktop.App); ok {
+		updateTray = func() {
+			lang := i18n.Language()
+			current := fyne.NewMenuItem(i18n.T("❌ No Mobile Suit selected"), showWindow)
+			if u := selectedUnit; u != nil {
+				current.Label = i18n.Tf("🎯 %s / %s (%d)", u.DisplayTitle(lang), u.DisplayName(lang), u.Value)
+				if writers[targetPID()] != nil {
+					current.Label = i18n.Tf("⏳ Writing %s / %s (%d)", u.DisplayTitle(lang), u.DisplayName(lang), u.Value)
+				}
+			}
+			submenu := func(label string, units []Unit) *fyne.MenuItem {
+				item := fyne.NewMenuItem(i18n.T(label), nil)
+				var items []*fyne.MenuItem
+				for _, u := range units {
+					items = append(items, fyne.NewMenuItem(fmt.Sprintf("%s / %s", u.DisplayTitle(lang), u.DisplayName(lang)), func() { switchUnit(u) }))
+				}
+				item.ChildMenu = fyne.NewMenu("", items...)
+				item.Disabled = len(items) == 0
+				return item
+			}
+			var favorites []Unit
+			for _, v := range cfg.Load().Favorites {
+				if i := slices.IndexFunc(allUnits, func(u Unit) bool { return u.Value == v }); i >= 0 {
+					favorites = append(favorites, allUnits[i])
+				}
+			}
+			start := fyne.NewMenuItem(i18n.T("🚀 Start Writing"), startButton.OnTapped)
+			start.Disabled = selectedUnit == nil || writers[targetPID()] != nil
+			stop := fyne.NewMenuItem(i18n.T("⏹ Stop"), func() { stopWriter(false) })
+			stop.Disabled = writers[targetPID()] == nil
+			restoreItem := fyne.NewMenuItem("↩️ "+i18n.T("Restore previous unit"), restore)
+			restoreItem.Disabled = writers[targetPID()] == nil
+			desk.SetSystemTrayMenu(fyne.NewMenu("MS Changer",
+				current,
+				fyne.NewMenuItemSeparator(),
+				submenu("⭐ Favorites", favorites),
+				submenu("🕘 Recent", recent),
+				fyne.NewMenuItemSeparator(),
+				start, stop, restoreItem,
+				fyne.NewMenuItemSeparator(),
+				fyne.NewMenuItem(i18n.T("🪟 Show window"), showWindow),
+			))
+		}
+		desk.SetSystemTrayIcon(theme.ComputerIcon())
+		updateTray()
+		w.SetCloseIntercept(func() {
+			if cfg.Load().CloseToTray {
+				w.Hide()
+				return
+			}
+			w.Close()
+		})
+	}
 
-## ✨ Features
-- 🎮 **Real-time Mobile Suit switching** during gameplay
-- 🔍 **Search functionality** to quickly find your favorite Mobile Suit
-- 📁 **Organized by series** with intuitive tab navigation
-- 🚀 **Easy-to-use GUI** with visual feedback
-- ⚡ **Instant application** of changes
+	handleCommand = func(command string, args []string) (msg string, err error) {
+		fyne.DoAndWait(func() {
+			switch command {
+			case "show":
+				showWindow()
+			case "write":
+				var u Unit
+				if u, err = unitByArg(args); err != nil {
+					return
+				}
+				switchUnit(u)
+				if writers[targetPID()] == nil {
+					startButton.OnTapped()
+				}
+				msg = fmt.Sprintf("writing %s / %s (%d)", u.Title, u.MS, u.Value)
+			default:
+				err = fmt.Errorf("unknown command %q", command)
+			}
+		})
+		report(slog.LevelInfo, "📨 Command received", "command", strings.Join(append([]string{command}, args...), " "))
+		return msg, err
+	}
+	if guard != nil {
+		go guard.Serve(handleCommand)
+	}
+	if err := listen(cfg.Load().ServerPort); err != nil {
+		report(slog.LevelWarn, "⚠️ Server port unavailable", "port", cfg.Load().ServerPort, "err", err)
+	}
+	defer listen(0)
 
-## 🎯 Supported Games
-- **EXVS2 Overboost** (vsac27_Release_CLIENT.exe)
+	w.SetContent(mainTabs)
 
-## ⚠️ Important Notes
-- ✅ **Run as Administrator** for memory access
-- 🛡️ **For educational and personal use only**
-- 🕹️ **Game must be running** before starting operations
+	w.ShowAndRun()
+}
 
----
-*Version 2.0 - Enhanced GUI Edition*`)
+// createAdditionalPages adds the About and Usage pages. They are generated
+// from the running configuration and rebuilt by the returned function
+// whenever one of them is shown.
+func createAdditionalPages(w fyne.Window) (about, usage *container.TabItem, refresh func()) {
+	// About page
+	aboutContent := widget.NewRichText()
+	report := &diagnostics.Report{}
+	copyButton := widget.NewButtonWithIcon(i18n.T("Copy diagnostics"), theme.ContentCopyIcon(), func() {
+		w.Clipboard().SetContent(report.String())
+	})
 
 	aboutScroll := container.NewVScroll(aboutContent)
 	aboutScroll.SetMinSize(fyne.NewSize(850, 500))
-	
-	// Usage Instructions page
-	usageContent := widget.NewRichTextFromMarkdown(`# 📖 Usage Instructions
-
-## 🚀 Getting Started
-
-### 1. Prerequisites
-- Windows operating system
-- Administrator privileges
-- Target game process running
-
-### 2. Launch Sequence
-1. **Start the game** (vsac27_Release_CLIENT.exe)
-2. **Run MS Changer as Administrator**
-3. Wait for the green "✅ Game process found" message
-
-### 3. Select Mobile Suit
-1. Use the **🔍 Search** box to filter Mobile Suits
-2. **Click on tabs** to browse by series (🚀 Gundam, ⭐ MSV, etc.)
-3. **Select your desired Mobile Suit** from the radio buttons
-
-### 4. Apply Changes
-1. Click **🚀 Start Writing** to begin memory modification
-2. The progress bar will show **⏳ Writing...** status  
-3. **Switch to game** and observe the Mobile Suit change
-4. Click **⏹ Stop** when finished
-
-## 💡 Tips
-- Search works for both Mobile Suit names and series titles
-- Tab numbers show how many Mobile Suits are in each series
-- Status messages provide real-time feedback
-- Changes apply immediately while writing is active
-
-## 🔧 Troubleshooting
-- Ensure game is running before starting
-- Run as Administrator if process access fails
-- Check that the correct .exe and .csv files are present`)
 
+	// Usage Instructions page
+	usageContent := widget.NewRichText()
 	usageScroll := container.NewVScroll(usageContent)
 	usageScroll.SetMinSize(fyne.NewSize(850, 500))
 
-	// Settings/Info page
-	settingsContent := widget.NewRichTextFromMarkdown(`# ⚙️ Settings & Information
-
-## 📁 File Structure
-- **ms-changer-gui.exe** - Main GUI application
-- **ms-changer-gui-cli.exe** - CLI helper (called by GUI)
-- **ms-changer.exe** - Standalone CLI version
-- **units.csv** - Mobile Suit database
-
-## 🔧 Memory Configuration
-- **Base Pointer Offset**: 0x1EA0708
-- **Fixed Offset**: 0x524
-- **Target Process**: vsac27_Release_CLIENT.exe
-- **Write Interval**: 1 second
-
-## 📊 CSV Format
-` + "```" + `csv
-id,title,ms,value
-1,機動戦士ガンダム,ガンダム,1001001
-2,機動戦士ガンダム,シャア専用ゲルググ,1002001
-` + "```" + `
-
-## 🎨 Interface Elements
-- **Tabs**: Series organization with count display
-- **Search**: Real-time filtering of Mobile Suits
-- **Progress**: Visual feedback during operations
-- **Status**: Detailed operation information
-
-## ⚠️ Safety Notes
-- Always backup save data before use
-- Only use with legitimate game copies
-- Respect online play guidelines
-- Educational purposes only`)
-
-	settingsScroll := container.NewVScroll(settingsContent)
-	settingsScroll.SetMinSize(fyne.NewSize(850, 500))
-
-	// Add all pages to main tabs
-	mainTabs.Append(container.NewTabItem("📋 About", aboutScroll))
-	mainTabs.Append(container.NewTabItem("📖 Usage", usageScroll))
-	mainTabs.Append(container.NewTabItem("⚙️ Settings", settingsScroll))
+	refresh = func() {
+		s := cfg.Load()
+		report = &diagnostics.Report{
+			Version:      diagnostics.Version(),
+			SettingsFile: cfgPath,
+			ProfileFile:  profilePath(s.Profile),
+			Profile:      prof,
+			UnitsFile:    unitsSource.String(),
+			UnitRows:     len(allUnits),
+			Engine:       engineState(),
+		}
+		seen := map[int32]string{}
+		for _, u := range allUnits {
+			if other, ok := seen[u.Value]; ok {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("value %d is used by %s and %s", u.Value, other, u.MS))
+			}
+			seen[u.Value] = u.MS
+		}
+		report.Warnings = append(report.Warnings, unitsSource.Conflicts...)
+		if missing := series.Missing(allUnits); len(missing) > 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("no series entry for %s", strings.Join(missing, ", ")))
+		}
+		games, err := diagnostics.Games(prof)
+		if err != nil {
+			report.Warnings = append(report.Warnings, err.Error())
+		}
+		report.Games = games
+		report.Check()
+
+		aboutContent.ParseMarkdown(i18n.T("# 📋 About MS Changer") + "\n\n" +
+			i18n.T("**MS Changer** is a utility for modifying the in-game Mobile Suit selection of a Windows-based arcade client via memory editing.") + "\n\n" +
+			i18n.T("## 🎯 Target") + "\n" +
+			i18n.Tf("- **Process**: %s", prof.Process) + "\n" +
+			i18n.Tf("- **Profile**: %s (%s)", prof.Name, profilePath(s.Profile)) + "\n" +
+			i18n.Tf("- **Units**: %d from %s", len(allUnits), unitsSource) + "\n\n" +
+			i18n.T("## ⚠️ Important Notes") + "\n" +
+			i18n.T("- ✅ **Run as Administrator** for memory access") + "\n" +
+			i18n.T("- 🛡️ **For educational and personal use only**") + "\n\n" +
+			i18n.T("## 🩺 Diagnostics") + "\n" +
+			i18n.T("Include this block when asking for help.") + "\n\n" +
+			"```\n" + report.String() + "```")
+
+		hotkeys := ""
+		for _, action := range settings.Actions {
+			if k := s.Hotkeys[action]; k != "" {
+				hotkeys += fmt.Sprintf("- **%s**: %s\n", k, i18n.T(action))
+			}
+		}
+		if hotkeys == "" {
+			hotkeys = i18n.T("- none configured") + "\n"
+		}
+		restoreNote := ""
+		if s.RestoreOnStop {
+			restoreNote = i18n.T("; the previous unit is written back")
+		}
+		usageContent.ParseMarkdown(i18n.T("# 📖 Usage Instructions") + "\n\n" +
+			i18n.T("## 🚀 Getting Started") + "\n" +
+			i18n.Tf("1. **Start the game** (%s)", prof.Process) + "\n" +
+			i18n.T("2. **Run MS Changer as Administrator**") + "\n" +
+			i18n.T("3. Pick the game in the **Instance** list if several are running") + "\n\n" +
+			i18n.T("## 🤖 Select Mobile Suit") + "\n" +
+			i18n.T("1. Use the **🔍 Search** box to filter Mobile Suits; ↑/↓ move through the list") + "\n" +
+			i18n.T("2. Click a column header to sort, or untick **Group by series** for a flat list") + "\n" +
+			i18n.T("3. **Select your desired Mobile Suit** in the list") + "\n\n" +
+			i18n.T("## ✏️ Apply Changes") + "\n" +
+			i18n.Tf("1. Click **🚀 Start Writing**; the unit is written every %s (%s)", time.Duration(s.WriteInterval), i18n.T(s.FreezeStrategy)) + "\n" +
+			i18n.T("2. Click **⏹ Stop** when finished") + restoreNote + "\n\n" +
+			i18n.T("## ⌨️ Hotkeys") + "\n" + hotkeys + "\n" +
+			i18n.T("Change these in **⚙️ Settings**.") + "\n\n" +
+			i18n.T("## 🔧 Troubleshooting") + "\n" +
+			i18n.T("- Check the **🩺 Diagnostics** block on the About page") + "\n" +
+			i18n.T("- The **🪵 Logs** tab shows what the writer did") + "\n" +
+			i18n.T("- Run as Administrator if process access fails"))
+	}
+	refresh()
+
+	about = container.NewTabItem(i18n.T("📋 About"), container.NewBorder(nil, container.NewHBox(copyButton), nil, nil, aboutScroll))
+	usage = container.NewTabItem(i18n.T("📖 Usage"), usageScroll)
+	return about, usage, refresh
 }
 
-func updateAccordion(searchQuery string, selectedID binding.String) {
-	// Clear existing tabs and radio groups
-	for len(accordion.Items) > 0 {
-		accordion.RemoveIndex(0)
+// targetPID is the client the selected instance stands for: the one picked,
+// or the first one found.
+func targetPID() uint32 {
+	if selectedPID != 0 {
+		return selectedPID
 	}
-	radioGroups = make(map[string]*widget.RadioGroup)
-	
-	// Group units by title
-	titleGroups := make(map[string][]Unit)
-	for _, unit := range allUnits {
-		// Filter by search query if provided
-		if searchQuery != "" {
-			if !strings.Contains(strings.ToLower(unit.MS), strings.ToLower(searchQuery)) &&
-			   !strings.Contains(strings.ToLower(unit.Title), strings.ToLower(searchQuery)) {
-				continue
+	return autoPID
+}
+
+// engineState describes what the writers are doing, for diagnostics.
+func engineState() string {
+	if len(writers) == 0 {
+		return "idle"
+	}
+	var parts []string
+	for pid, unit := range writerUnits {
+		parts = append(parts, fmt.Sprintf("writing %s to PID %d", unit, pid))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+// createSettingsPage edits the user's settings. Save validates them, puts
+// them into effect through apply and writes them to the settings file.
+func createSettingsPage(apply func(*settings.Settings) error, report func(slog.Level, string, ...any)) *container.TabItem {
+	cur := cfg.Load()
+
+	unitsEntry := widget.NewEntry()
+	unitsEntry.SetText(cur.UnitsFile)
+	overridesEntry := widget.NewEntry()
+	overridesEntry.SetText(strings.Join(cur.Overrides, ", "))
+	overridesEntry.SetPlaceHolder(i18n.T("e.g. team.csv, mine.csv"))
+	profileEntry := widget.NewEntry()
+	profileEntry.SetText(cur.Profile)
+	intervalEntry := widget.NewEntry()
+	intervalEntry.SetText(time.Duration(cur.WriteInterval).String())
+	freezeSelect := widget.NewSelect([]string{settings.FreezeContinuous, settings.FreezeOnce}, nil)
+	freezeSelect.SetSelected(cur.FreezeStrategy)
+	restoreCheck := widget.NewCheck(i18n.T("Write the previous unit back when stopping"), nil)
+	restoreCheck.SetChecked(cur.RestoreOnStop)
+	trayCheck := widget.NewCheck(i18n.T("Keep running in the system tray when the window is closed"), nil)
+	trayCheck.SetChecked(cur.CloseToTray)
+	languageSelect := widget.NewSelect(settings.Languages, nil)
+	languageSelect.SetSelected(cur.Language)
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(cur.ServerPort))
+
+	form := widget.NewForm(
+		widget.NewFormItem(i18n.T("Units file"), unitsEntry),
+		widget.NewFormItem(i18n.T("Override files"), overridesEntry),
+		widget.NewFormItem(i18n.T("Pointer profile"), profileEntry),
+		widget.NewFormItem(i18n.T("Write interval"), intervalEntry),
+		widget.NewFormItem(i18n.T("Freeze strategy"), freezeSelect),
+		widget.NewFormItem(i18n.T("Restore on stop"), restoreCheck),
+		widget.NewFormItem(i18n.T("Close to tray"), trayCheck),
+		widget.NewFormItem(i18n.T("Language"), languageSelect),
+	)
+	hotkeyEntries := map[string]*widget.Entry{}
+	for _, action := range settings.Actions {
+		e := widget.NewEntry()
+		e.SetText(cur.Hotkeys[action])
+		e.SetPlaceHolder(i18n.T("e.g. Ctrl+Return"))
+		hotkeyEntries[action] = e
+		form.Append(i18n.Tf("Hotkey: %s", action), e)
+	}
+	form.Append(i18n.T("Server port (0 = off)"), portEntry)
+
+	result := widget.NewLabel("")
+	result.Wrapping = fyne.TextWrapWord
+	save := widget.NewButtonWithIcon(i18n.T("Save"), theme.DocumentSaveIcon(), func() {
+		s := &settings.Settings{
+			UnitsFile:      strings.TrimSpace(unitsEntry.Text),
+			Profile:        strings.TrimSpace(profileEntry.Text),
+			FreezeStrategy: freezeSelect.Selected,
+			RestoreOnStop:  restoreCheck.Checked,
+			CloseToTray:    trayCheck.Checked,
+			Language:       languageSelect.Selected,
+			Hotkeys:        map[string]string{},
+			// not on this page
+			TrustedKeys: cfg.Load().TrustedKeys,
+			Favorites:   cfg.Load().Favorites,
+		}
+		for _, o := range strings.Split(overridesEntry.Text, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				s.Overrides = append(s.Overrides, o)
 			}
 		}
-		titleGroups[unit.Title] = append(titleGroups[unit.Title], unit)
+		interval, err := time.ParseDuration(strings.TrimSpace(intervalEntry.Text))
+		if err != nil {
+			result.SetText(i18n.T("❌ Write interval must be a duration like 1s or 500ms"))
+			return
+		}
+		s.WriteInterval = profile.Duration(interval)
+		if s.ServerPort, err = strconv.Atoi(strings.TrimSpace(portEntry.Text)); err != nil {
+			result.SetText(i18n.T("❌ Server port must be a number"))
+			return
+		}
+		for action, e := range hotkeyEntries {
+			s.Hotkeys[action] = strings.TrimSpace(e.Text)
+		}
+
+		if err := apply(s); err != nil {
+			result.SetText("❌ " + err.Error())
+			return
+		}
+		if cfgPath == "" {
+			result.SetText(i18n.T("⚠️ Applied, but there is no config directory to save to"))
+			return
+		}
+		if err := s.Save(cfgPath); err != nil {
+			result.SetText(i18n.T("❌ Applied, but saving failed: ") + err.Error())
+			return
+		}
+		msg := i18n.T("✅ Saved to ") + cfgPath
+		if s.Language != cur.Language {
+			msg += "\n" + i18n.T("🌐 Restart MS Changer to switch every label to the new language.")
+		}
+		result.SetText(msg)
+		report(slog.LevelInfo, "⚙️ Settings saved", "file", cfgPath)
+	})
+	save.Importance = widget.HighImportance
+
+	page := container.NewVBox(
+		widget.NewRichTextFromMarkdown(i18n.T("# ⚙️ Settings")),
+		form,
+		save,
+		result,
+	)
+	return container.NewTabItem(i18n.T("⚙️ Settings"), container.NewVScroll(page))
+}
+
+// newSearchField returns a search box that leaves the configured hotkeys
+// to the window: a focused Entry gets every shortcut first and would
+// swallow them (Ctrl+Backspace deletes a word).
+func newSearchField() *unitlist.SearchField {
+	e := unitlist.NewSearchField()
+	e.OnShortcut = func(sc fyne.Shortcut) bool {
+		run := hotkeyRuns[sc.ShortcutName()]
+		if run != nil {
+			run()
+		}
+		return run != nil
 	}
-	
-	// Sort titles
-	var titles []string
-	for title := range titleGroups {
-		titles = append(titles, title)
+	return e
+}
+
+// registerHotkeys replaces the window shortcuts with the ones configured in
+// s. Shortcuts that cannot be parsed are skipped; Validate reports them.
+func registerHotkeys(c fyne.Canvas, s *settings.Settings, actions map[string]func()) {
+	for _, sc := range hotkeys {
+		c.RemoveShortcut(sc)
 	}
-	sort.Strings(titles)
-	
-	// Create tabs for each title
-	for _, title := range titles {
-		units := titleGroups[title]
-		
-		// Create radio group for this title
-		var radioItems []string
-		unitMap := make(map[string]Unit)
-		
-		for _, unit := range units {
-			label := fmt.Sprintf("🤖 %s", unit.MS)
-			radioItems = append(radioItems, label)
-			unitMap[label] = unit
-		}
-		
-		if len(radioItems) > 0 {
-			radio := widget.NewRadioGroup(radioItems, func(selected string) {
-				if unit, exists := unitMap[selected]; exists {
-					selectedUnit = &unit
-					selectedID.Set(strconv.Itoa(int(unit.Value)))
-				}
-			})
-			radio.Horizontal = false
-			
-			// Add emoji based on series
-			titleIcon := "📺"
-			if strings.Contains(title, "ガンダム") {
-				titleIcon = "🚀"
-			} else if strings.Contains(title, "MSV") {
-				titleIcon = "⭐"
+	hotkeys = nil
+	clear(hotkeyRuns)
+
+	for action, text := range s.Hotkeys {
+		run := actions[action]
+		mods, key, err := settings.ParseShortcut(text)
+		if err != nil || run == nil {
+			continue
+		}
+		sc := &desktop.CustomShortcut{KeyName: fyne.KeyName(key)}
+		if strings.EqualFold(key, "enter") {
+			sc.KeyName = fyne.KeyReturn
+		} else if len(key) == 1 {
+			sc.KeyName = fyne.KeyName(strings.ToUpper(key))
+		}
+		for _, m := range mods {
+			switch m {
+			case "Ctrl":
+				sc.Modifier |= fyne.KeyModifierControl
+			case "Alt":
+				sc.Modifier |= fyne.KeyModifierAlt
+			case "Shift":
+				sc.Modifier |= fyne.KeyModifierShift
+			case "Super":
+				sc.Modifier |= fyne.KeyModifierSuper
 			}
-			
-			tabTitle := fmt.Sprintf("%s %s (%d)", titleIcon, title, len(units))
-			
-			// Store radio group for tab switching
-			radioGroups[tabTitle] = radio
-			
-			// Set default selection for first tab
-			if len(accordion.Items) == 0 && len(radioItems) > 0 {
-				radio.Selected = radioItems[0]
-				if unit, exists := unitMap[radioItems[0]]; exists {
-					selectedUnit = &unit
-					selectedID.Set(strconv.Itoa(int(unit.Value)))
-				}
+		}
+		c.AddShortcut(sc, func(fyne.Shortcut) { run() })
+		hotkeys = append(hotkeys, sc)
+		hotkeyRuns[sc.ShortcutName()] = run
+	}
+}
+
+// createLogPage shows the most recent entries of the audit log written by
+// ms-changer-gui-cli.exe.
+func createLogPage() (*container.TabItem, func()) {
+	logText := widget.NewLabel("")
+	logText.Wrapping = fyne.TextWrapWord
+
+	refresh := func() {
+		path, err := auditlog.DefaultPath()
+		if err != nil {
+			logText.SetText(i18n.Tf("❌ Failed to read %s: %v", auditlog.FileName, err))
+			return
+		}
+		entries, err := auditlog.Read(path)
+		if err != nil {
+			logText.SetText(i18n.Tf("❌ Failed to read %s: %v", path, err))
+			return
+		}
+		entries = auditlog.Tail(entries, 50)
+		if len(entries) == 0 {
+			logText.SetText(i18n.T("📭 Nothing has been written yet."))
+			return
+		}
+
+		var lines []string
+		for i := len(entries) - 1; i >= 0; i-- {
+			icon := "✅"
+			if entries[i].Result != auditlog.ResultOK {
+				icon = "❌"
 			}
-			
-			scrollContent := container.NewVScroll(radio)
-			scrollContent.SetMinSize(fyne.NewSize(800, 300))
-			
-			tabItem := container.NewTabItem(tabTitle, scrollContent)
-			accordion.Append(tabItem)
+			lines = append(lines, icon+" "+entries[i].String())
 		}
+		logText.SetText(strings.Join(lines, "\n"))
 	}
-	
-	// If no results found, show message
-	if len(accordion.Items) == 0 {
-		noResultsLabel := widget.NewLabel("🔍 No Mobile Suits found matching your search")
-		noResultsLabel.Alignment = fyne.TextAlignCenter
-		accordion.Append(container.NewTabItem("❌ No Results", noResultsLabel))
-	} else {
-		// Auto-select the first tab
-		if len(accordion.Items) > 0 {
-			accordion.SelectTab(accordion.Items[0])
+
+	header := container.NewBorder(nil, nil,
+		widget.NewRichTextFromMarkdown(i18n.T("## 📜 Recent Writes")), widget.NewButtonWithIcon(i18n.T("Refresh"), theme.ViewRefreshIcon(), refresh),
+	)
+	logScroll := container.NewVScroll(logText)
+	logScroll.SetMinSize(fyne.NewSize(850, 500))
+
+	return container.NewTabItem(i18n.T("📜 Write Log"), container.NewBorder(header, nil, nil, nil, logScroll)), refresh
+}
+
+// createLogViewerPage lists the log records of the GUI and of the writer
+// runs, newest first, filtered by level.
+func createLogViewerPage() (*container.TabItem, func()) {
+	logText := widget.NewLabel("")
+	logText.Wrapping = fyne.TextWrapWord
+	minLevel := slog.LevelInfo
+
+	refresh := func() {
+		records := logs.Records(minLevel)
+		if len(records) == 0 {
+			logText.SetText(i18n.T("📭 No log records yet."))
+			return
 		}
+		lines := make([]string, 0, len(records))
+		for i := len(records) - 1; i >= 0; i-- {
+			lines = append(lines, records[i].String())
+		}
+		logText.SetText(strings.Join(lines, "\n"))
 	}
+
+	levelSelect := widget.NewSelect([]string{"DEBUG", "INFO", "WARN", "ERROR"}, func(s string) {
+		minLevel, _ = logging.ParseLevel(s)
+		refresh()
+	})
+	levelSelect.SetSelected("INFO")
+
+	header := container.NewBorder(nil, nil,
+		widget.NewRichTextFromMarkdown(i18n.T("## 🪵 Logs")),
+		container.NewHBox(levelSelect, widget.NewButtonWithIcon(i18n.T("Refresh"), theme.ViewRefreshIcon(), refresh)),
+	)
+	logScroll := container.NewVScroll(logText)
+	logScroll.SetMinSize(fyne.NewSize(850, 500))
+
+	return container.NewTabItem(i18n.T("🪵 Logs"), container.NewBorder(header, nil, nil, nil, logScroll)), refresh
 }
 
-func loadUnitsFromCSV(filename string) []Unit {
-	f, err := os.Open(filename)
-	if err != nil {
-		fmt.Println("❌ Failed to open CSV:", err)
-		return nil
+// updateUnitList shows the units matching the search box and the cost
+// filter. Only the rows are rebuilt, so typing stays fast.
+func updateUnitList(searchQuery string) {
+	units := allUnits
+	if searchQuery != "" {
+		// Best matches first within each series
+		units = nil
+		for _, m := range searchIndex.Search(searchQuery, 0) {
+			units = append(units, m.Unit)
+		}
 	}
-	defer f.Close()
+	unitView.Show(unitFilter.Apply(units))
+}
 
-	records, err := csv.NewReader(f).ReadAll()
-	if err != nil {
-		fmt.Println("❌ Failed to parse CSV:", err)
-		return nil
+// paletteCommand is an action of the command palette. It is found by its
+// English or translated name and by its keywords.
+type paletteCommand struct {
+	icon     string
+	name     string
+	keywords []string
+	run      func()
+}
+
+// paletteItem is a result shown in the command palette.
+type paletteItem struct {
+	label string
+	score int
+	run   func()
+}
+
+// palette is the open command palette, so the hotkey does not open a
+// second one.
+var palette *widget.PopUp
+
+// showPalette opens the command palette: a search box over commands,
+// series and units, ranked together by the search index. ↑/↓ pick a
+// result, Enter runs it and Escape closes the palette.
+func showPalette(c fyne.Canvas, commands []paletteCommand, pickUnit func(Unit), pickSeries func(unitdb.Series)) {
+	if palette != nil && palette.Visible() {
+		return
 	}
+	var items []paletteItem
+	cur := 0
+	moving := false // Select is called by the keys, not a click
 
-	var units []Unit
-	titleOrder := map[string]int{}
-	orderCounter := 0
+	entry := newSearchField()
+	entry.SetPlaceHolder(i18n.T("🔍 Type a unit, title or command..."))
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			l := widget.NewLabel("")
+			l.Truncation = fyne.TextTruncateEllipsis
+			return l
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(items[id].label)
+		},
+	)
+	run := func(i int) {
+		if i < 0 || i >= len(items) {
+			return
+		}
+		palette.Hide()
+		items[i].run()
+	}
+	selectItem := func(i int) {
+		if len(items) == 0 {
+			list.UnselectAll()
+			return
+		}
+		cur = min(max(i, 0), len(items)-1)
+		moving = true
+		list.Select(cur)
+		list.ScrollTo(cur)
+		moving = false
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		cur = id
+		if !moving {
+			run(id)
+		}
+	}
+	entry.OnChanged = func(query string) {
+		items = paletteItems(query, commands, pickUnit, pickSeries)
+		list.Refresh()
+		selectItem(0)
+	}
+	entry.OnSubmitted = func(string) { run(cur) }
+	entry.OnKey = func(key fyne.KeyName) bool {
+		switch key {
+		case fyne.KeyDown:
+			selectItem(cur + 1)
+		case fyne.KeyUp:
+			selectItem(cur - 1)
+		case fyne.KeyPageDown:
+			selectItem(cur + 10)
+		case fyne.KeyPageUp:
+			selectItem(cur - 10)
+		case fyne.KeyEscape:
+			palette.Hide()
+		default:
+			return false
+		}
+		return true
+	}
+	entry.OnChanged("")
 
-	for i, r := range records {
-		if i == 0 || len(r) < 4 {
-			continue
+	palette = widget.NewModalPopUp(container.NewBorder(entry, nil, nil, nil, list), c)
+	palette.Resize(fyne.NewSize(600, 400))
+	palette.Show()
+	c.Focus(entry)
+}
+
+// paletteItems ranks the commands, series and units matching query, best
+// first; an empty query lists the commands. On equal scores commands come
+// before series and series before units.
+func paletteItems(query string, commands []paletteCommand, pickUnit func(Unit), pickSeries func(unitdb.Series)) []paletteItem {
+	var items []paletteItem
+	for _, cmd := range commands {
+		label := cmd.icon + " " + i18n.T(cmd.name)
+		if query == "" {
+			items = append(items, paletteItem{label, 0, cmd.run})
+		} else if score := searchIndex.Score(query, append([]string{cmd.name, i18n.T(cmd.name)}, cmd.keywords...)...); score > 0 {
+			items = append(items, paletteItem{label, score, cmd.run})
+		}
+	}
+	if query == "" {
+		return items
+	}
+
+	lang := i18n.Language()
+	for _, g := range series.Group(allUnits) {
+		s := g.Series
+		if score := searchIndex.Score(query, s.Name, s.NameEN, s.Short, s.Code, s.Title); score > 0 {
+			label := i18n.Tf("%s %s (%d units)", s.Icon, s.DisplayName(lang), len(g.Units))
+			items = append(items, paletteItem{label, score, func() { pickSeries(s) }})
 		}
-		id, _ := strconv.Atoi(r[0])
-		value, _ := strconv.Atoi(r[3])
-		title := r[1]
+	}
+	for _, m := range searchIndex.Search(query, 30) {
+		u := m.Unit
+		label := fmt.Sprintf("🤖 %s / %s (%d)", u.DisplayTitle(lang), u.DisplayName(lang), u.Value)
+		items = append(items, paletteItem{label, m.Score, func() { pickUnit(u) }})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].score > items[j].score })
+	return items[:min(len(items), 50)]
+}
 
-		// Track appearance order of titles
-		if _, exists := titleOrder[title]; !exists {
-			titleOrder[title] = orderCounter
-			orderCounter++
+// unitByArg finds the unit named by a forwarded "write" command: a unit ID,
+// or else a unit value.
+func unitByArg(args []string) (Unit, error) {
+	if len(args) != 1 {
+		return Unit{}, fmt.Errorf("usage: write <id|value>")
+	}
+	n, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return Unit{}, fmt.Errorf("%q is not a unit ID or value", args[0])
+	}
+	for _, match := range []func(Unit) bool{
+		func(u Unit) bool { return u.ID == int32(n) },
+		func(u Unit) bool { return u.Value == int32(n) },
+	} {
+		if i := slices.IndexFunc(allUnits, match); i >= 0 {
+			return allUnits[i], nil
 		}
+	}
+	return Unit{}, fmt.Errorf("no unit with ID or value %d", n)
+}
 
-		units = append(units, Unit{
-			ID:    int32(id),
-			Title: title,
-			MS:    r[2],
-			Value: int32(value),
-		})
+// watchedFiles lists the files a reload depends on: every place the units,
+// override, aliases, series and profile files are looked for, so a newly
+// created file is noticed too.
+func watchedFiles(s *settings.Settings) []string {
+	var paths []string
+	for _, name := range append([]string{s.UnitsFile, unitdb.AliasesPath(s.UnitsFile), unitdb.SeriesPath(s.UnitsFile), s.Profile}, s.Overrides...) {
+		paths = append(paths, unitdb.SearchPaths(name)...)
+	}
+	return paths
+}
+
+// profilePath finds a pointer profile the way the units file is found, so
+// a profile installed from a bundle next to the units file is the one
+// loaded. A profile found nowhere keeps its name (and the built-in chain).
+func profilePath(name string) string {
+	if path := unitdb.Find(name); path != "" {
+		return path
+	}
+	return name
+}
+
+// loadSeries loads the series file next to the units file.
+func loadSeries(unitsFile string) *unitdb.Catalog {
+	c, err := unitdb.OpenSeries(unitsFile, defaultSeries)
+	if err != nil {
+		slog.Warn("⚠️ Ignoring series file", "err", err)
+	}
+	return c
+}
+
+// buildSearchIndex indexes units together with the aliases file next to
+// the units file.
+func buildSearchIndex(unitsFile string, units []Unit) *unitdb.Index {
+	aliases, err := unitdb.OpenAliases(unitsFile, defaultAliases)
+	if err != nil {
+		slog.Warn("⚠️ Ignoring aliases file", "err", err)
+	}
+	return unitdb.NewIndex(units, aliases)
+}
+
+// loadUnitsFromCSV loads the units file with its overrides (any supported
+// format, searched for like unitdb.Open) in release order of the titles.
+func loadUnitsFromCSV(filename string, overrides []string) ([]Unit, unitdb.Source) {
+	units, src, err := unitdb.Open(filename, defaultUnits, overrides...)
+	if err != nil {
+		slog.Error("❌ Failed to load units", "file", filename, "err", err)
+		return nil, src
+	}
+
+	// Track appearance order of titles
+	titleOrder := map[string]int{}
+	for _, u := range units {
+		if _, exists := titleOrder[u.Title]; !exists {
+			titleOrder[u.Title] = len(titleOrder)
+		}
 	}
 
 	// Sort by appearance order, then by ID
@@ -475,25 +1416,5 @@ func loadUnitsFromCSV(filename string) []Unit {
 		return ti < tj
 	})
 
-	return units
-}
-
-func getProcessID(name string) (uint32, error) {
-	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
-	if err != nil {
-		return 0, err
-	}
-	defer windows.CloseHandle(snap)
-
-	var entry windows.ProcessEntry32
-	entry.Size = uint32(unsafe.Sizeof(entry))
-	err = windows.Process32First(snap, &entry)
-	for err == nil {
-		exe := syscall.UTF16ToString(entry.ExeFile[:])
-		if strings.EqualFold(exe, name) {
-			return entry.ProcessID, nil
-		}
-		err = windows.Process32Next(snap, &entry)
-	}
-	return 0, fmt.Errorf("Process %s not found", name)
+	return units, src
 }
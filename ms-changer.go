@@ -2,258 +2,1377 @@ package main
 
 import (
 	"bufio"
-	"encoding/csv"
+	"bytes"
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
-	"syscall"
+	"sync"
+	"text/tabwriter"
 	"time"
-	"unsafe"
 
-	"golang.org/x/sys/windows"
+	"ms-changer/auditlog"
+	"ms-changer/bundle"
+	"ms-changer/diagnostics"
+	"ms-changer/gameproc"
+	"ms-changer/i18n"
+	"ms-changer/instance"
+	"ms-changer/logging"
+	"ms-changer/memory"
+	"ms-changer/profile"
+	"ms-changer/settings"
+	"ms-changer/unitdb"
 )
 
-type Unit struct {
-	Title string
-	Name  string
-	ID    int32
-}
+type Unit = unitdb.Unit
 
-const (
-	PROCESS_VM_READ                   = 0x0010
-	PROCESS_VM_WRITE                  = 0x0020
-	PROCESS_VM_OPERATION              = 0x0008
-	PROCESS_QUERY_INFORMATION         = 0x0400
-	
-	// Base RVA from CE screenshot
-	baseRVA                   uintptr = 0x020023B8
-)
+// defaultUnits is used when no units file is found next to the working
+// directory, the executable or in the user config dir.
+//
+//go:embed units.csv
+var defaultUnits []byte
+
+//go:embed aliases.csv
+var defaultAliases []byte
+
+//go:embed series.csv
+var defaultSeries []byte
 
 var unitList = make(map[int32]Unit)
 var sortedIDs []int32 // Sorted list of unit IDs
 
-func loadUnitsFromCSV(filename string) error {
-	file, err := os.Open(filename)
+func loadUnitsFromCSV(filename string, overrides []string) (unitdb.Source, error) {
+	units, src, err := unitdb.Open(filename, defaultUnits, overrides...)
 	if err != nil {
-		return err
+		return src, err
+	}
+	for _, u := range units {
+		unitList[u.ID] = u
+		sortedIDs = append(sortedIDs, u.ID)
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	sort.Slice(sortedIDs, func(i, j int) bool {
+		return sortedIDs[i] < sortedIDs[j]
+	})
+
+	return src, nil
+}
+
+// loadSettings reads the user's settings file, falling back to the defaults
+// when there is none or it is broken.
+func loadSettings() *settings.Settings {
+	path, err := settings.Path()
 	if err != nil {
-		return err
+		return settings.Default()
+	}
+	s, err := settings.Load(path)
+	if err != nil {
+		slog.Warn("⚠️ Ignoring settings file", "err", err)
+		return settings.Default()
 	}
+	return s
+}
 
-	for i, record := range records {
-		if i == 0 {
-			continue // Skip header
-		}
-		if len(record) < 4 {
-			continue
-		}
-		id, err := strconv.Atoi(record[0])
-		if err != nil {
-			fmt.Printf("⚠️ Failed to convert id (%s): %v\n", record[0], err)
-			continue
-		}
-		unitID, err := strconv.Atoi(record[3])
-		if err != nil {
-			fmt.Printf("⚠️ Failed to convert unitID (%s): %v\n", record[3], err)
-			continue
-		}
-		unitList[int32(id)] = Unit{
-			Title: record[1],
-			Name:  record[2],
-			ID:    int32(unitID),
+func waitForGame(prof *profile.Profile) []gameproc.Process {
+	for {
+		procs, err := gameproc.List(prof.ProcessMatcher())
+		if err == nil && len(procs) > 0 {
+			return procs
 		}
-		sortedIDs = append(sortedIDs, int32(id))
+		time.Sleep(time.Duration(prof.RetryInterval))
 	}
+}
 
-	sort.Slice(sortedIDs, func(i, j int) bool {
-		return sortedIDs[i] < sortedIDs[j]
-	})
+// selectProcess picks the client instance to attach to: the one given by
+// -pid, the only one running, or one chosen by the user.
+func selectProcess(prof *profile.Profile, pid uint32, reader *bufio.Reader) (gameproc.Process, error) {
+	if pid != 0 {
+		return gameproc.Find(prof.ProcessMatcher(), pid)
+	}
 
-	return nil
-}
+	procs := waitForGame(prof)
+	if len(procs) == 1 {
+		return procs[0], nil
+	}
 
-func waitForGame(processName string) uint32 {
+	fmt.Println(i18n.Tf("🖥 %d game instances are running:", len(procs)))
+	for i, p := range procs {
+		fmt.Printf("  %d: %s %s\n", i+1, p, p.CommandLine)
+	}
 	for {
-		pid, err := getProcessID(processName)
-		if err == nil {
-			return pid
+		fmt.Print(i18n.T("Select instance: "))
+		input, _ := reader.ReadString('\n')
+		n, err := strconv.Atoi(strings.TrimSpace(input))
+		if err == nil && n >= 1 && n <= len(procs) {
+			return procs[n-1], nil
 		}
-		time.Sleep(2 * time.Second) // Retry every 2 seconds
+		fmt.Println(i18n.T("❌ Please enter a number from the list."))
 	}
 }
 
 func main() {
-	err := loadUnitsFromCSV("units.csv")
-	if err != nil {
-		fmt.Printf("Failed to load CSV: %v\n", err)
+	pidFlag := flag.Uint("pid", 0, "PID of the game instance to attach to")
+	profileFlag := flag.String("profile", "", "pointer profile to load (default from settings, profile.json)")
+	processFlag := flag.String("process", "", "process name, glob or re:<regexp> (overrides profile)")
+	moduleFlag := flag.String("module", "", "module holding the chain base (overrides profile)")
+	retryFlag := flag.Duration("retry", 0, "interval between attempts to find the game (overrides profile)")
+	snapshotFlag := flag.String("snapshot", "", "work on a captured snapshot instead of the running game")
+	langFlag := flag.String("lang", "", "language of the messages: en or ja (default from settings)")
+	logOpts := logging.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if err := logOpts.Setup(os.Stderr); err != nil {
+		fmt.Println("❌", err)
 		return
 	}
 
-	processName := "vsac27_Release_CLIENT.exe"
+	cfg := loadSettings()
+	if *langFlag == "" {
+		*langFlag = cfg.Language
+	}
+	if err := i18n.SetLanguage(*langFlag); err != nil {
+		slog.Warn("⚠️ Using English", "err", err)
+	}
+	logging.Translate = i18n.T
+	if *profileFlag == "" {
+		*profileFlag = cfg.Profile
+	}
+	*profileFlag = profilePath(*profileFlag)
 
-	fmt.Println("🔍 Waiting for game process to start...")
+	prof, err := profile.Load(*profileFlag)
+	if err != nil {
+		slog.Error("❌ Failed to load profile", "err", err)
+		return
+	}
+	if err := prof.Override(*processFlag, *moduleFlag, *retryFlag); err != nil {
+		slog.Error("❌ Invalid profile", "err", err)
+		return
+	}
 
-	pid := waitForGame(processName)
+	switch cmd := flag.Arg(0); cmd {
+	case "":
+	case "log":
+		runLog(flag.Args()[1:])
+		return
+	case "db":
+		runDB(cfg, flag.Args()[1:])
+		return
+	case "diag":
+		runDiag(prof, *profileFlag, cfg)
+		return
+	case "search":
+		runSearch(cfg, flag.Args()[1:])
+		return
+	case "list":
+		runList(cfg, flag.Args()[1:])
+		return
+	case "random":
+		runRandom(cfg, flag.Args()[1:])
+		return
+	case "write":
+		runWrite(prof, cfg, uint32(*pidFlag), *snapshotFlag, flag.Args()[1:])
+		return
+	case "resolve", "read", "scan", "snapshot":
+		a, err := attachSelected(prof, uint32(*pidFlag), *snapshotFlag, bufio.NewReader(os.Stdin))
+		if err != nil {
+			slog.Error("❌ Failed to attach", "err", err)
+			return
+		}
+		defer a.close()
 
-	handle, err := openProcess(pid)
-	if err != nil {
-		fmt.Printf("Failed to open process: %v\n", err)
+		args := flag.Args()[1:]
+		switch cmd {
+		case "resolve":
+			runResolve(prof, a, args)
+		case "read":
+			runRead(prof, a, cfg)
+		case "scan":
+			runScan(a, args)
+		case "snapshot":
+			runSnapshot(prof, a, args)
+		}
+		return
+	default:
+		slog.Error("❌ Unknown command", "name", cmd)
 		return
 	}
-	defer syscall.CloseHandle(handle)
 
-	moduleBase, err := getModuleBaseAddress(pid, processName)
+	src, err := loadUnitsFromCSV(cfg.UnitsFile, cfg.Overrides)
 	if err != nil {
-		fmt.Printf("Failed to get module base address: %v\n", err)
+		slog.Error("❌ Failed to load CSV", "err", err)
 		return
 	}
+	slog.Info("📂 Units loaded", "source", src, "count", len(sortedIDs))
+
+	reader := bufio.NewReader(os.Stdin)
 
-	// Pointer chain from CE screenshot
-	offsets := []uintptr{0x4A0, 0x108, 0x440, 0x188, 0x38, 0x534}
-	
-	addr := moduleBase + baseRVA
-	
-	// Follow the pointer chain
-	for _, offset := range offsets {
-		var nextAddr uintptr
-		_, _, readErr := syscall.NewLazyDLL("kernel32.dll").NewProc("ReadProcessMemory").Call(
-			uintptr(handle),
-			addr,
-			uintptr(unsafe.Pointer(&nextAddr)),
-			unsafe.Sizeof(nextAddr),
-			0,
-		)
-		if readErr != syscall.Errno(0) {
-			fmt.Printf("Failed to resolve pointer at 0x%X: %v\n", addr, readErr)
+	if *snapshotFlag == "" {
+		in, ok := acquireInstance()
+		if !ok {
+			slog.Error("❌ Another MS Changer is already writing to the game; send it a unit with \"ms-changer write <id>\"")
 			return
 		}
-		addr = nextAddr + offset
+		if in != nil {
+			defer in.Close()
+		}
+		slog.Info("🔍 Waiting for game process to start...", "process", prof.Process)
+	}
+
+	a, err := attachSelected(prof, uint32(*pidFlag), *snapshotFlag, reader)
+	if err == nil {
+		err = a.resolveUnit(prof)
+		if err != nil {
+			a.close()
+		}
+	}
+	if err != nil {
+		slog.Error("❌ Failed to attach", "err", err)
+		return
+	}
+	slog.Info("🟢 Attached", "pid", a.pid, "target", fmt.Sprintf("0x%X", a.targetAddr))
+	g := &gameConn{cur: a}
+	defer func() { g.current().close() }()
+
+	audit, err := auditlog.OpenDefault()
+	if err != nil {
+		slog.Warn("⚠️ Audit log disabled", "err", err)
 	}
-	
-	targetAddr := addr
-	reader := bufio.NewReader(os.Stdin)
 
 	for {
-		fmt.Println("==== Unit List (Grouped by Title) ====")
+		fmt.Println(i18n.T("==== Unit List (Grouped by Title) ===="))
 
 		currentTitle := ""
 		for _, id := range sortedIDs {
 			unit := unitList[id]
-			if unit.Title != currentTitle {
-				fmt.Printf("\n[%s]\n", unit.Title)
-				currentTitle = unit.Title
+			if title := unit.DisplayTitle(i18n.Language()); title != currentTitle {
+				fmt.Printf("\n[%s]\n", title)
+				currentTitle = title
 			}
-			fmt.Printf("  %d: %s\n", id, unit.Name)
+			fmt.Printf("  %d: %s%s\n", id, unit.DisplayName(i18n.Language()), metaSuffix(unit))
 		}
 
-		fmt.Print("Enter ID (Press TAB to stop writing and reselect): ")
+		fmt.Print(i18n.T("Enter ID (Press TAB to stop writing and reselect): "))
 		input, _ := reader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
 		id64, err := strconv.ParseInt(input, 10, 32)
 		if err != nil {
-			fmt.Println("❌ Please enter a valid numeric ID.")
+			fmt.Println(i18n.T("❌ Please enter a valid numeric ID."))
 			continue
 		}
 		id := int32(id64)
 
 		unit, exists := unitList[id]
 		if !exists {
-			fmt.Println("❌ The entered ID does not exist in the list.")
+			fmt.Println(i18n.T("❌ The entered ID does not exist in the list."))
 			continue
 		}
 
-		fmt.Printf("✅ %s, %s (%d) Writing started...\n", unit.Title, unit.Name, unit.ID)
+		slog.Info("✅ Writing started...", "title", unit.Title, "unit", unit.MS, "value", unit.Value)
 
 		stopChan := make(chan struct{})
+		done := make(chan struct{})
 
-		go func(unitID int32, stop chan struct{}) {
+		go func(stop chan struct{}) {
+			defer close(done)
+			w := &writer{prof: prof, unit: unit, audit: audit}
 			for {
 				select {
 				case <-stop:
+					if cfg.RestoreOnStop {
+						w.restore(g.current())
+					}
 					return
 				default:
-					syscall.NewLazyDLL("kernel32.dll").NewProc("WriteProcessMemory").Call(
-						uintptr(handle),
-						targetAddr,
-						uintptr(unsafe.Pointer(&unitID)),
-						unsafe.Sizeof(unitID),
-						0,
-					)
-					time.Sleep(1 * time.Second)
+					cur := g.current()
+					if cur.exited() {
+						slog.Warn("💀 Game process exited", "pid", cur.pid)
+						if !g.reattach(prof, stop) {
+							return
+						}
+						cur = g.current()
+						slog.Info("▶ Resuming", "unit", unit.MS, "value", unit.Value)
+						w.reattached()
+					}
+					if w.step(cur) && cfg.FreezeStrategy == settings.FreezeOnce {
+						slog.Info("✅ Written once; press TAB to pick another unit.")
+						<-stop
+						continue
+					}
+					time.Sleep(time.Duration(cfg.WriteInterval))
 				}
 			}
-		}(unit.ID, stopChan)
+		}(stopChan)
 
-		fmt.Println("💡 Press TAB to stop writing and reselect.")
+		fmt.Println(i18n.T("💡 Press TAB to stop writing and reselect."))
 
 		for {
 			char, _ := reader.ReadByte()
 			if char == '\t' {
 				close(stopChan)
-				fmt.Println("⏹ Writing stopped.")
+				<-done
+				slog.Info("⏹ Writing stopped.")
 				break
 			}
 		}
 	}
 }
 
-func getProcessID(processName string) (uint32, error) {
-	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+// instanceDir is where the single-instance lock and socket live: next to
+// the settings file.
+func instanceDir() string {
+	path, err := settings.Path()
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(path)
+}
+
+// acquireInstance takes the single-instance lock before writing to the
+// game, and reports false when another MS Changer holds it. Commands sent
+// to this process meanwhile are refused: the CLI takes its units from the
+// keyboard. Without a config directory there is no lock.
+func acquireInstance() (*instance.Instance, bool) {
+	dir := instanceDir()
+	if dir == "" {
+		return nil, true
+	}
+	in, err := instance.Acquire(dir)
+	if errors.Is(err, instance.ErrRunning) {
+		return nil, false
+	}
+	if err != nil {
+		slog.Warn("⚠️ Single-instance lock unavailable", "err", err)
+		return nil, true
+	}
+	go in.Serve(func(command string, args []string) (string, error) {
+		return "", fmt.Errorf("ms-changer.exe (PID %d) is writing; pick the unit there", os.Getpid())
+	})
+	return in, true
+}
+
+// runWrite implements "ms-changer write <id|value>": it hands the unit to
+// the MS Changer that is already running, or, when there is none, writes
+// it once itself.
+func runWrite(prof *profile.Profile, cfg *settings.Settings, pid uint32, snapshot string, args []string) {
+	if len(args) != 1 {
+		slog.Error("❌ Usage: ms-changer write <id|value>")
+		return
+	}
+	if snapshot == "" {
+		in, ok := acquireInstance()
+		if !ok {
+			reply, err := instance.Send(instanceDir(), "write", args[0])
+			if err != nil {
+				slog.Error("❌ The running MS Changer did not take the command", "err", err)
+				return
+			}
+			slog.Info("📨 Sent to the running MS Changer", "reply", reply)
+			return
+		}
+		if in != nil {
+			defer in.Close()
+		}
+	}
+
+	if _, err := loadUnitsFromCSV(cfg.UnitsFile, cfg.Overrides); err != nil {
+		slog.Error("❌ Failed to load CSV", "err", err)
+		return
+	}
+	n, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		slog.Error("❌ Please enter a valid numeric ID.")
+		return
+	}
+	unit, ok := unitList[int32(n)]
+	if !ok {
+		for _, u := range unitList {
+			if u.Value == int32(n) {
+				unit, ok = u, true
+			}
+		}
+	}
+	if !ok {
+		slog.Error("❌ The entered ID does not exist in the list.")
+		return
+	}
+
+	a, err := attachSelected(prof, pid, snapshot, bufio.NewReader(os.Stdin))
+	if err == nil {
+		if err = a.resolveUnit(prof); err != nil {
+			a.close()
+		}
+	}
+	if err != nil {
+		slog.Error("❌ Failed to attach", "err", err)
+		return
+	}
+	defer a.close()
+
+	entry, written := writeUnit(a, unit.Value)
+	if !written {
+		slog.Info("✅ Already selected", "unit", unit.MS, "value", unit.Value)
+		return
+	}
+	entry.Profile, entry.UnitID, entry.UnitName = prof.Name, unit.ID, unit.MS
+	logWrite(entry)
+	if audit, err := auditlog.OpenDefault(); err != nil {
+		slog.Warn("⚠️ Audit log disabled", "err", err)
+	} else {
+		audit.Write(entry)
+	}
+	if entry.Result == auditlog.ResultOK {
+		slog.Info("✅ Written", "title", unit.Title, "unit", unit.MS, "value", unit.Value)
+	}
+}
+
+// writer is the state of the write loop for one unit: whether the write is
+// queued behind a scene watch, the last error logged, and the unit the game
+// held before our first write, for restore-on-stop.
+type writer struct {
+	prof     *profile.Profile
+	unit     Unit
+	audit    *auditlog.Logger // nil when the audit log is disabled
+	waiting  bool
+	lastErr  string
+	original *int32
+}
+
+// step runs one round of the write loop on a. While a scene watch blocks
+// writing, the write stays queued; otherwise the unit is written unless the
+// game already holds it. It reports whether the game holds the unit now.
+func (w *writer) step(a *attachment) bool {
+	if w.prof.Gated() {
+		ok, blocking := w.prof.CanApply(a.readWatches(w.prof))
+		if !ok {
+			if !w.waiting {
+				slog.Info("⏸ Queued: waiting for the game to allow writing...", "watch", blocking)
+				w.waiting = true
+			}
+			return false
+		}
+		if w.waiting {
+			slog.Info("▶ Safe scene detected, writing.")
+			w.waiting = false
+		}
+	}
+	entry, ok := writeUnit(a, w.unit.Value)
+	if !ok {
+		return true
+	}
+	entry.Profile, entry.UnitID, entry.UnitName = w.prof.Name, w.unit.ID, w.unit.MS
+	if entry.Result == auditlog.ResultOK && w.original == nil && entry.Previous != 0 {
+		prev := entry.Previous
+		w.original = &prev
+	}
+	// Keep a failing write from filling the log every second.
+	if entry.Error == "" || entry.Error != w.lastErr {
+		w.log(entry)
+	}
+	w.lastErr = entry.Error
+	return entry.Result == auditlog.ResultOK
+}
+
+// restore writes back the unit the game held before our first write.
+func (w *writer) restore(a *attachment) {
+	if w.original == nil {
+		return
+	}
+	if entry, ok := writeUnit(a, *w.original); ok {
+		entry.Profile, entry.UnitName = w.prof.Name, w.unit.MS+" (restore)"
+		w.log(entry)
+	}
+	slog.Info("↩️ Restored previous unit", "value", *w.original)
+}
+
+// reattached forgets the previous run of the game: the unit it held must
+// not be restored into the new one.
+func (w *writer) reattached() {
+	w.waiting, w.lastErr, w.original = false, "", nil
+}
+
+func (w *writer) log(entry auditlog.Entry) {
+	logWrite(entry)
+	if w.audit != nil {
+		w.audit.Write(entry)
+	}
+}
+
+// logWrite reports the outcome of a write recorded in the audit log.
+func logWrite(e auditlog.Entry) {
+	attrs := []any{"pid", e.PID, "value", e.Value, "previous", e.Previous, "latency_us", e.LatencyUS}
+	switch e.Result {
+	case auditlog.ResultFailed:
+		slog.Error("❌ Write failed", append(attrs, "err", e.Error)...)
+	case auditlog.ResultReverted:
+		slog.Warn("⚠️ Write did not stick", attrs...)
+	default:
+		slog.Debug("write ok", attrs...)
+	}
+}
+
+// writeUnit writes value to the unit address unless it already holds it,
+// and reads it back to see whether the write stuck. It reports false when
+// nothing had to be written.
+func writeUnit(a *attachment, value int32) (auditlog.Entry, bool) {
+	prev, err := memory.ReadInt32(a.mem, a.targetAddr)
+	if err == nil && prev == value {
+		return auditlog.Entry{}, false
+	}
+
+	entry := auditlog.Entry{Time: time.Now(), PID: a.pid, Value: value, Previous: prev, Result: auditlog.ResultOK}
+	if err := memory.WriteInt32(a.mem, a.targetAddr, value); err != nil {
+		entry.Result, entry.Error = auditlog.ResultFailed, err.Error()
+	} else if v, err := memory.ReadInt32(a.mem, a.targetAddr); err == nil && v != value {
+		entry.Result = auditlog.ResultReverted
+	}
+	entry.LatencyUS = time.Since(entry.Time).Microseconds()
+	return entry, true
+}
+
+// runSearch implements "ms-changer search <query>": it lists the units
+// matching the query by name, title, romaji or alias, best first.
+func runSearch(cfg *settings.Settings, args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	n := fs.Int("n", 20, "number of results to show")
+	var filter unitdb.Filter
+	filter.RegisterFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Println(i18n.T("❌ Usage: ms-changer search [-n 20] <query>"))
+		return
+	}
+
+	units, src, err := unitdb.Open(cfg.UnitsFile, defaultUnits, cfg.Overrides...)
+	if err != nil {
+		slog.Error("❌ Failed to load CSV", "err", err)
+		return
+	}
+	aliases, err := unitdb.OpenAliases(src.Path, defaultAliases)
+	if err != nil {
+		slog.Warn("⚠️ Ignoring aliases file", "err", err)
+	}
+
+	lang := i18n.Language()
+	matches := unitdb.NewIndex(filter.Apply(units), aliases).Search(strings.Join(fs.Args(), " "), *n)
+	if len(matches) == 0 {
+		fmt.Println(i18n.T("🔍 No Mobile Suits found matching your search"))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range matches {
+		fmt.Fprintf(w, "%d\t%d\t(%d)\t%s / %s\t%s\n", m.Unit.ID, m.Unit.Value, m.Score, m.Unit.DisplayTitle(lang), m.Unit.DisplayName(lang), m.Unit.Meta())
+	}
+	w.Flush()
+}
+
+// loadFiltered loads the units database and applies the metadata filter flags
+// in args.
+func loadFiltered(cfg *settings.Settings, name string, args []string) ([]Unit, bool) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	var filter unitdb.Filter
+	filter.RegisterFlags(fs)
+	fs.Parse(args)
+
+	units, _, err := unitdb.Open(cfg.UnitsFile, defaultUnits, cfg.Overrides...)
+	if err != nil {
+		slog.Error("❌ Failed to load CSV", "err", err)
+		return nil, false
+	}
+	units = filter.Apply(units)
+	if len(units) == 0 {
+		fmt.Println(i18n.T("🔍 No Mobile Suits found matching your search"))
+		return nil, false
+	}
+	return units, true
+}
+
+// runList implements "ms-changer list": the units grouped by series in
+// the order of series.csv, with their metadata, optionally filtered by
+// -cost, -dlc, -version and -tag.
+func runList(cfg *settings.Settings, args []string) {
+	units, ok := loadFiltered(cfg, "list", args)
+	if !ok {
+		return
+	}
+	series, err := unitdb.OpenSeries(cfg.UnitsFile, defaultSeries)
+	if err != nil {
+		slog.Warn("⚠️ Ignoring series file", "err", err)
+	}
+	lang := i18n.Language()
+	for _, g := range series.Group(units) {
+		if g.Year != 0 {
+			fmt.Printf("\n%s [%s] (%d)\n", g.Icon, g.DisplayName(lang), g.Year)
+		} else {
+			fmt.Printf("\n%s [%s]\n", g.Icon, g.DisplayName(lang))
+		}
+		for _, u := range g.Units {
+			fmt.Printf("  %d: %s%s\n", u.ID, u.DisplayName(lang), metaSuffix(u))
+		}
+	}
+}
+
+// runRandom implements "ms-changer random": it picks one of the units
+// passing the filter flags, e.g. a random 3000 cost for practice.
+func runRandom(cfg *settings.Settings, args []string) {
+	units, ok := loadFiltered(cfg, "random", args)
+	if !ok {
+		return
+	}
+	lang := i18n.Language()
+	u := units[rand.IntN(len(units))]
+	fmt.Println(i18n.Tf("🎲 %d: %s / %s (%d)%s", u.ID, u.DisplayTitle(lang), u.DisplayName(lang), u.Value, metaSuffix(u)))
+}
+
+// metaSuffix formats the unit's metadata for the unit lists.
+func metaSuffix(u Unit) string {
+	if m := u.Meta(); m != "" {
+		return "  [" + m + "]"
+	}
+	return ""
+}
+
+// runDiag implements "ms-changer diag": it prints the effective
+// configuration and what the unit chain points to in every running client.
+func runDiag(prof *profile.Profile, profileFile string, cfg *settings.Settings) {
+	r := &diagnostics.Report{
+		Version:     diagnostics.Version(),
+		ProfileFile: profileFile,
+		Profile:     prof,
+		UnitsFile:   cfg.UnitsFile,
+		Engine:      "not running (CLI)",
+	}
+	r.SettingsFile, _ = settings.Path()
+
+	src, err := loadUnitsFromCSV(cfg.UnitsFile, cfg.Overrides)
+	if err != nil {
+		r.Warnings = append(r.Warnings, err.Error())
+	}
+	r.UnitsFile = src.String()
+	r.Warnings = append(r.Warnings, src.Conflicts...)
+	r.UnitRows = len(sortedIDs)
+	r.Warnings = append(r.Warnings, duplicateValues()...)
+	r.Warnings = append(r.Warnings, missingSeries(cfg.UnitsFile)...)
+
+	games, err := diagnostics.Games(prof)
+	if err != nil {
+		r.Warnings = append(r.Warnings, err.Error())
+	}
+	r.Games = games
+	r.Check()
+	fmt.Print(r)
+}
+
+// duplicateValues reports unit values listed under more than one ID.
+func duplicateValues() []string {
+	seen := make(map[int32]int32)
+	var warnings []string
+	for _, id := range sortedIDs {
+		unit := unitList[id]
+		if first, ok := seen[unit.Value]; ok {
+			warnings = append(warnings, fmt.Sprintf("value %d is listed as ID %d and %d", unit.Value, first, id))
+			continue
+		}
+		seen[unit.Value] = id
+	}
+	return warnings
+}
+
+// missingSeries reports titles that have no entry in the series file.
+func missingSeries(unitsFile string) []string {
+	series, err := unitdb.OpenSeries(unitsFile, defaultSeries)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var units []Unit
+	for _, id := range sortedIDs {
+		units = append(units, unitList[id])
+	}
+	if missing := series.Missing(units); len(missing) > 0 {
+		return []string{fmt.Sprintf("no series entry for %s", strings.Join(missing, ", "))}
+	}
+	return nil
+}
+
+// runDB implements "ms-changer db": comparing and exporting databases,
+// importing database bundles and rolling back to the previous version.
+func runDB(cfg *settings.Settings, args []string) {
+	if len(args) == 0 || !slices.Contains([]string{"diff", "export", "import", "rollback"}, args[0]) {
+		fmt.Println(i18n.T("❌ Usage: ms-changer db diff <old> <new> | db export [-format csv|json|yaml|md] [-sort title|id|value] [-o file] [file] | db import [-yes] [-dry-run] [-key base64] <bundle.zip> | db rollback"))
+		return
+	}
+	switch args[0] {
+	case "diff":
+		runDBDiff(args[1:])
+		return
+	case "export":
+		runDBExport(cfg, args[1:])
+		return
+	}
+	dir := databaseDir(cfg)
+
+	if args[0] == "rollback" {
+		restored, err := bundle.Rollback(dir)
+		if err != nil {
+			slog.Error("❌ Rollback failed", "err", err)
+			return
+		}
+		slog.Info("↩️ Restored the previous database", "backup", restored)
+		return
+	}
+
+	fs := flag.NewFlagSet("db import", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "install without asking")
+	dryRun := fs.Bool("dry-run", false, "only verify the bundle and show the changes")
+	key := fs.String("key", "", "trusted ed25519 public key (base64), in addition to trusted_keys in the settings")
+	fs.Parse(args[1:])
+	if fs.NArg() != 1 {
+		fmt.Println(i18n.T("❌ Usage: ms-changer db import [-yes] [-dry-run] [-key base64] <bundle.zip> | db rollback"))
+		return
+	}
+
+	var keys []ed25519.PublicKey
+	for _, k := range append(slices.Clone(cfg.TrustedKeys), *key) {
+		if k == "" {
+			continue
+		}
+		pk, err := bundle.ParseKey(k)
+		if err != nil {
+			slog.Error("❌ Invalid public key", "key", k, "err", err)
+			return
+		}
+		keys = append(keys, pk)
+	}
+
+	b, err := bundle.Open(fs.Arg(0))
+	if err != nil {
+		slog.Error("❌ Invalid bundle", "err", err)
+		return
+	}
+	if err := b.Verify(keys); err != nil {
+		slog.Error("❌ Invalid bundle", "err", err)
+		return
+	}
+	m := b.Manifest
+	switch {
+	case b.Verified:
+		fmt.Println(i18n.Tf("🔏 Bundle %s, signature verified", m.Version))
+	case b.Signed:
+		fmt.Println(i18n.Tf("⚠️ Bundle %s is signed, but no trusted key is configured to check it", m.Version))
+	default:
+		fmt.Println(i18n.Tf("⚠️ Bundle %s is not signed", m.Version))
+	}
+	if cur := bundle.Installed(dir); cur != nil {
+		fmt.Println(i18n.Tf("📦 Installed: %s", cur.Version))
+	}
+
+	units, err := unitdb.Parse(b.Files[m.Units], unitdb.FormatOf(m.Units), m.Units)
+	if err != nil || len(units) == 0 {
+		slog.Error("❌ Invalid bundle", "err", fmt.Errorf("%s: no units (%v)", m.Units, err))
+		return
+	}
+	current, _, err := unitdb.Open(cfg.UnitsFile, defaultUnits)
+	if err != nil {
+		slog.Warn("⚠️ No current database to compare with", "err", err)
+	}
+	diffs := unitdb.Diff(current, units)
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	fmt.Println(i18n.Tf("📊 %s: %s", m.Units, unitdb.Summary(diffs)))
+	for name, data := range b.Files {
+		if name == m.Units {
+			continue
+		}
+		old, err := os.ReadFile(filepath.Join(dir, name))
+		switch {
+		case err != nil:
+			fmt.Println(i18n.Tf("📊 %s: new", name))
+		case !bytes.Equal(old, data):
+			fmt.Println(i18n.Tf("📊 %s: changed", name))
+		}
+	}
+	if filepath.Base(cfg.UnitsFile) != m.Units {
+		slog.Warn("⚠️ The bundle's units file differs from units_file in the settings", "bundle", m.Units, "units_file", cfg.UnitsFile)
+	}
+	if *dryRun {
+		return
+	}
+
+	if !*yes {
+		fmt.Print(i18n.Tf("Install into %s? [y/N] ", dir))
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println(i18n.T("Cancelled"))
+			return
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Error("❌ Install failed", "err", err)
+		return
+	}
+	backup, err := b.Install(dir)
+	if err != nil {
+		slog.Error("❌ Install failed, nothing was changed", "err", err)
+		return
+	}
+	slog.Info("✅ Bundle installed", "version", m.Version, "dir", dir, "backup", backup)
+	for name := range b.Files {
+		if !bundle.IsProfile(m, name) {
+			continue
+		}
+		installed := filepath.Join(dir, name)
+		if name != filepath.Base(cfg.Profile) {
+			slog.Warn("⚠️ The bundle's profile is not the one in the settings", "bundle", installed, "profile", cfg.Profile)
+		} else if used := profilePath(cfg.Profile); !sameFile(used, installed) {
+			slog.Warn("⚠️ Another profile file is loaded instead of the installed one", "installed", installed, "loaded", used)
+		}
+	}
+}
+
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	return err == nil && os.SameFile(ia, ib)
+}
+
+// runDBDiff implements "ms-changer db diff old new": the units added,
+// removed, renamed, revalued or otherwise changed, matched by value and id.
+func runDBDiff(args []string) {
+	if len(args) != 2 {
+		fmt.Println(i18n.T("❌ Usage: ms-changer db diff <old> <new>"))
+		return
+	}
+	old, err := unitdb.Load(args[0])
+	if err != nil {
+		slog.Error("❌ Failed to load CSV", "err", err)
+		return
+	}
+	new, err := unitdb.Load(args[1])
+	if err != nil {
+		slog.Error("❌ Failed to load CSV", "err", err)
+		return
+	}
+	diffs := unitdb.Diff(old, new)
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	fmt.Println(i18n.Tf("📊 %s: %s", args[1], unitdb.Summary(diffs)))
+}
+
+// runDBExport implements "ms-changer db export": the database in use (or
+// the given file) converted to another format.
+func runDBExport(cfg *settings.Settings, args []string) {
+	fs := flag.NewFlagSet("db export", flag.ExitOnError)
+	format := fs.String("format", "csv", "output format: "+strings.Join(unitdb.ExportFormats, ", "))
+	sortBy := fs.String("sort", "id", "sort by "+strings.Join(unitdb.SortKeys, ", "))
+	out := fs.String("o", "", "write to this file instead of standard output")
+	fs.Parse(args)
+
+	var units []Unit
+	var err error
+	if fs.NArg() > 0 {
+		units, err = unitdb.Load(fs.Arg(0))
+	} else {
+		units, _, err = unitdb.Open(cfg.UnitsFile, defaultUnits, cfg.Overrides...)
+	}
+	if err != nil {
+		slog.Error("❌ Failed to load CSV", "err", err)
+		return
+	}
+	if err := unitdb.Sort(units, *sortBy); err != nil {
+		slog.Error("❌ Export failed", "err", err)
+		return
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			slog.Error("❌ Export failed", "err", err)
+			return
+		}
+		defer w.Close()
+	}
+	if err := unitdb.Export(w, units, *format); err != nil {
+		slog.Error("❌ Export failed", "err", err)
+	}
+}
+
+// databaseDir is where bundles are installed: next to the units file in
+// use, or the user config dir when the embedded copy is used.
+func databaseDir(cfg *settings.Settings) string {
+	if path := unitdb.Find(cfg.UnitsFile); path != "" {
+		return filepath.Dir(path)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "ms-changer")
+	}
+	return "."
+}
+
+// profilePath finds a pointer profile the way the units file is found: as
+// given, next to the executable, then in the config dir, so a profile
+// installed from a bundle next to the units file is the one loaded. A
+// profile found nowhere keeps its name (and the built-in chain).
+func profilePath(name string) string {
+	if path := unitdb.Find(name); path != "" {
+		return path
+	}
+	return name
+}
+
+// runLog implements "ms-changer log tail [-n 20]" and
+// "ms-changer log query [-since 1h] [-unit name]".
+func runLog(args []string) {
+	if len(args) == 0 || (args[0] != "tail" && args[0] != "query") {
+		fmt.Println(i18n.T("❌ Usage: ms-changer log tail [-n 20] | log query [-since 1h|2006-01-02T15:04:05] [-unit <id|value|name>]"))
+		return
+	}
+
+	fs := flag.NewFlagSet("log "+args[0], flag.ExitOnError)
+	defaultPath, _ := auditlog.DefaultPath()
+	path := fs.String("file", defaultPath, "audit log to read")
+	n := fs.Int("n", 20, "number of entries to show (tail)")
+	since := fs.String("since", "", "only entries newer than a duration ago or a timestamp (query)")
+	unit := fs.String("unit", "", "only entries for this unit id, value or name (query)")
+	asJSON := fs.Bool("json", false, "print entries as JSON Lines")
+	fs.Parse(args[1:])
+
+	entries, err := auditlog.Read(*path)
+	if err != nil {
+		slog.Error("❌ Failed to read audit log", "err", err)
+		return
+	}
+
+	if args[0] == "tail" {
+		entries = auditlog.Tail(entries, *n)
+	} else {
+		var from time.Time
+		if *since != "" {
+			if d, err := time.ParseDuration(*since); err == nil {
+				from = time.Now().Add(-d)
+			} else if from, err = time.ParseInLocation("2006-01-02T15:04:05", *since, time.Local); err != nil {
+				fmt.Println(i18n.T("❌ -since must be a duration like 1h or a time like 2006-01-02T15:04:05"))
+				return
+			}
+		}
+		entries = auditlog.Query(entries, from, *unit)
+	}
+
+	for _, e := range entries {
+		if *asJSON {
+			data, _ := json.Marshal(e)
+			fmt.Println(string(data))
+		} else {
+			fmt.Println(e)
+		}
+	}
+}
+
+// runResolve implements "ms-changer resolve [-explain] [-json] [-watch name]".
+func runResolve(prof *profile.Profile, a *attachment, args []string) {
+	fs := flag.NewFlagSet("resolve", flag.ExitOnError)
+	explain := fs.Bool("explain", false, "report every hop of the pointer chain")
+	asJSON := fs.Bool("json", false, "print the explanation as JSON")
+	watch := fs.String("watch", "", "resolve the named watch instead of the unit chain")
+	fs.Parse(args)
+
+	chain := prof.Unit
+	if *watch != "" {
+		found := false
+		for _, w := range prof.Watches {
+			if w.Name == *watch {
+				chain, found = w.Chain, true
+			}
+		}
+		if !found {
+			slog.Error("❌ No such watch in profile", "watch", *watch, "profile", prof.Name)
+			return
+		}
+	}
+
+	if !*explain {
+		addr, err := memory.Resolve(a.mem, a.module.Base, chain)
+		if err != nil {
+			slog.Error("❌ Failed to resolve chain", "err", err)
+			return
+		}
+		fmt.Printf("✏️ Target address: 0x%X\n", addr)
+		return
+	}
+
+	e := memory.Explain(a.mem, a.module.Base, chain)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(e)
+		return
+	}
+	printExplanation(e, a.module)
+}
+
+// runRead implements "ms-changer read": it prints the current unit value and
+// every watch value.
+func runRead(prof *profile.Profile, a *attachment, cfg *settings.Settings) {
+	if err := a.resolveUnit(prof); err != nil {
+		slog.Error("❌ Failed to resolve unit chain", "err", err)
+		return
+	}
+	value, err := memory.ReadInt32(a.mem, a.targetAddr)
 	if err != nil {
-		return 0, err
+		slog.Error("❌ Failed to read unit", "err", err)
+		return
 	}
-	defer windows.CloseHandle(snapshot)
 
-	var entry windows.ProcessEntry32
-	entry.Size = uint32(unsafe.Sizeof(entry))
-	err = windows.Process32First(snapshot, &entry)
-	for err == nil {
-		name := syscall.UTF16ToString(entry.ExeFile[:])
-		if strings.EqualFold(name, processName) {
-			return entry.ProcessID, nil
+	name := "unknown unit"
+	if _, err := loadUnitsFromCSV(cfg.UnitsFile, cfg.Overrides); err == nil {
+		for _, unit := range unitList {
+			if unit.Value == value {
+				name = unit.DisplayTitle(i18n.Language()) + " / " + unit.DisplayName(i18n.Language())
+			}
+		}
+	}
+	fmt.Println(i18n.Tf("🎮 Unit at 0x%X: %d (%s)", a.targetAddr, value, name))
+
+	values := a.readWatches(prof)
+	for _, w := range prof.Watches {
+		if v, ok := values[w.Name]; ok {
+			fmt.Printf("👁 %s = %d\n", w.Name, v)
+		} else {
+			fmt.Println(i18n.Tf("👁 %s: unreadable", w.Name))
 		}
-		err = windows.Process32Next(snapshot, &entry)
 	}
-	return 0, fmt.Errorf("Process %s not found", processName)
 }
 
-func getModuleBaseAddress(pid uint32, moduleName string) (uintptr, error) {
-	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPMODULE|windows.TH32CS_SNAPMODULE32, pid)
+// runSnapshot implements "ms-changer snapshot": it captures the memory every
+// chain of the profile touches so it can be replayed elsewhere with -snapshot.
+func runSnapshot(prof *profile.Profile, a *attachment, args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	out := fs.String("out", "snapshot-"+time.Now().Format("20060102-150405")+".json.gz", "file to write")
+	context := fs.Int("context", 256, "bytes to capture on each side of every address read")
+	fs.Parse(args)
+
+	chains := []profile.Chain{prof.Unit}
+	for _, w := range prof.Watches {
+		chains = append(chains, w.Chain)
+	}
+
+	snap, err := memory.Capture(a.mem, a.module.Base, chains, uintptr(*context))
 	if err != nil {
-		return 0, err
+		slog.Error("❌ Snapshot failed", "err", err)
+		return
+	}
+	snap.PID = a.pid
+	snap.Process = a.exe
+	snap.Profile = prof.Name
+
+	if err := snap.Save(*out); err != nil {
+		slog.Error("❌ Failed to save snapshot", "err", err)
+		return
+	}
+	size := 0
+	for _, b := range snap.Blocks {
+		size += len(b.Data)
 	}
-	defer windows.CloseHandle(snapshot)
+	fmt.Println(i18n.Tf("📸 Captured %d blocks (%d bytes) to %s", len(snap.Blocks), size, *out))
+}
+
+// runScan implements "ms-changer scan", a built-in pointer scan that finds
+// new chains after a game update:
+//
+//	ms-changer scan -addr 0x1A2B3C40 -value 1001001 -out pointerscan.json
+//	ms-changer scan -validate pointerscan.json -value 1001001   (after a restart)
+func runScan(a *attachment, args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	addrFlag := fs.String("addr", "", "address currently holding the unit value (hex)")
+	value := fs.Int("value", 0, "unit value expected at the address")
+	depth := fs.Int("depth", 7, "maximum chain length")
+	maxOffset := fs.String("max-offset", "0x1000", "maximum offset per hop (hex)")
+	maxResults := fs.Int("max-results", 200, "stop after this many chains")
+	out := fs.String("out", "pointerscan.json", "file to save candidate chains to (with -validate: <file>.validated.json)")
+	validate := fs.String("validate", "", "re-check chains saved by an earlier scan")
+	fs.Parse(args)
 
-	var me windows.ModuleEntry32
-	me.Size = uint32(unsafe.Sizeof(me))
-	err = windows.Module32First(snapshot, &me)
-	for err == nil {
-		modName := syscall.UTF16ToString(me.Module[:])
-		if strings.EqualFold(modName, moduleName) {
-			return uintptr(me.ModBaseAddr), nil
+	// Validating never replaces its input unless asked to: one run in the
+	// wrong scene would otherwise throw away every candidate.
+	if *validate != "" {
+		outSet := false
+		fs.Visit(func(f *flag.Flag) { outSet = outSet || f.Name == "out" })
+		if !outSet {
+			*out = strings.TrimSuffix(*validate, filepath.Ext(*validate)) + ".validated.json"
 		}
-		err = windows.Module32Next(snapshot, &me)
 	}
-	return 0, fmt.Errorf("Module %s not found", moduleName)
+
+	if *value == 0 || (*addrFlag == "" && *validate == "") {
+		fmt.Println(i18n.T("❌ Usage: ms-changer scan -addr <hex> -value <unitValue> | -validate <file> -value <unitValue>"))
+		return
+	}
+
+	var chains []profile.Chain
+	if *validate != "" {
+		data, err := os.ReadFile(*validate)
+		if err == nil {
+			err = json.Unmarshal(data, &chains)
+		}
+		if err != nil {
+			slog.Error("❌ Failed to read scan results", "err", err)
+			return
+		}
+		before := len(chains)
+		chains = memory.ValidateChains(a.mem, a.module.Base, chains, int32(*value))
+		fmt.Println(i18n.Tf("🔎 %d of %d chains still resolve to %d", len(chains), before, *value))
+	} else {
+		target, err1 := strconv.ParseUint(*addrFlag, 0, 64)
+		limit, err2 := strconv.ParseUint(*maxOffset, 0, 64)
+		if err1 != nil || err2 != nil {
+			fmt.Println(i18n.T("❌ -addr and -max-offset must be numbers, e.g. 0x1A2B3C40"))
+			return
+		}
+		if v, err := memory.ReadInt32(a.mem, uintptr(target)); err != nil || v != int32(*value) {
+			fmt.Println(i18n.Tf("❌ 0x%X does not hold %d", target, *value))
+			return
+		}
+
+		fmt.Println(i18n.Tf("🔍 Scanning for chains to 0x%X (depth %d, max offset 0x%X)...", target, *depth, limit))
+		var err error
+		chains, err = memory.ScanPointers(a.mem, a.module, uintptr(target), memory.ScanOptions{
+			MaxDepth:   *depth,
+			MaxOffset:  uintptr(limit),
+			MaxResults: *maxResults,
+		})
+		if err != nil {
+			slog.Error("❌ Scan failed", "err", err)
+			return
+		}
+		fmt.Println(i18n.Tf("🔎 Found %d chains", len(chains)))
+	}
+
+	for i, c := range chains {
+		if i == 10 {
+			fmt.Println(i18n.Tf("  ... and %d more in %s", len(chains)-i, *out))
+			break
+		}
+		data, _ := json.Marshal(c)
+		fmt.Printf("  %d: %s\n", i+1, data)
+	}
+
+	data, _ := json.MarshalIndent(chains, "", "  ")
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		slog.Error("❌ Failed to save scan results", "err", err)
+		return
+	}
+	fmt.Println(i18n.Tf("💾 Saved to %s. Restart the game and run scan -validate %s to narrow them down.", *out, *out))
 }
 
-func openProcess(pid uint32) (syscall.Handle, error) {
-	handle, _, err := syscall.NewLazyDLL("kernel32.dll").NewProc("OpenProcess").Call(
-		uintptr(PROCESS_VM_READ|PROCESS_VM_WRITE|PROCESS_VM_OPERATION|PROCESS_QUERY_INFORMATION),
-		0,
-		uintptr(pid),
-	)
-	h := syscall.Handle(handle)
-	if h == 0 || h == syscall.InvalidHandle {
-		return 0, fmt.Errorf("OpenProcess failed: %v", err)
+func printExplanation(e *memory.Explanation, mod memory.Module) {
+	fmt.Printf("🧩 Module %s at 0x%X (0x%X bytes)\n", mod.Name, mod.Base, mod.Size)
+	fmt.Printf("📌 Chain base: 0x%X\n", uintptr(e.Base))
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tREAD AT\tPOINTER\tOFFSET\tNEXT\tVALID\tMODULE\tHINT")
+	for _, h := range e.Hops {
+		if h.Error != "" {
+			fmt.Fprintf(tw, "%d\t0x%X\t-\t+0x%X\t-\t❌\t\t%s (%s)\n", h.Step, uintptr(h.Addr), uintptr(h.Offset), h.Hint, h.Error)
+			continue
+		}
+		valid := "✅"
+		if !h.Valid {
+			valid = "❌"
+		}
+		fmt.Fprintf(tw, "%d\t0x%X\t0x%X\t+0x%X\t0x%X\t%s\t%s\t%s\n",
+			h.Step, uintptr(h.Addr), uintptr(h.Pointer), uintptr(h.Offset), uintptr(h.Next), valid, h.Module, h.Hint)
+	}
+	tw.Flush()
+
+	if e.Target != 0 {
+		fmt.Printf("✏️ Target: 0x%X (writable: %v)", uintptr(e.Target), e.Writable)
+		if e.Value != nil {
+			fmt.Printf(", current value %d", *e.Value)
+		}
+		fmt.Println()
+	}
+	if e.Hint != "" {
+		fmt.Println("💡", e.Hint)
+	}
+}
+
+// attachment is the memory the tool works on: one run of the game client,
+// or a snapshot loaded with -snapshot.
+type attachment struct {
+	pid        uint32 // 0 for snapshots
+	exe        string
+	mem        memory.Process
+	module     memory.Module
+	targetAddr uintptr         // unit address, set by resolveUnit
+	siblings   map[uint32]bool // other instances running when we attached
+	closed     bool
+}
+
+// close releases the process handle; closing twice is harmless.
+func (a *attachment) close() {
+	if live, ok := a.mem.(memory.LiveProcess); ok && !a.closed {
+		live.Close()
+	}
+	a.closed = true
+}
+
+// exited reports whether the attached process has terminated, or the
+// attachment was closed. Snapshots never exit.
+func (a *attachment) exited() bool {
+	live, ok := a.mem.(memory.LiveProcess)
+	return a.closed || ok && live.Exited()
+}
+
+func (a *attachment) resolveUnit(prof *profile.Profile) error {
+	addr, err := memory.Resolve(a.mem, a.module.Base, prof.Unit)
+	if err != nil {
+		return err
+	}
+	a.targetAddr = addr
+	return nil
+}
+
+// readWatches reads the current value of every watch in the profile.
+func (a *attachment) readWatches(prof *profile.Profile) map[string]int32 {
+	return memory.ReadWatches(a.mem, a.module.Base, prof.Watches)
+}
+
+// attachProcess opens the process and locates the module the chains start from.
+func attachProcess(proc gameproc.Process, prof *profile.Profile) (*attachment, error) {
+	mem, err := memory.OpenProcess(proc.PID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open process: %v", err)
+	}
+
+	mod, err := memory.FindModule(mem, prof.ModuleName(proc.Exe))
+	if err != nil {
+		mem.Close()
+		return nil, fmt.Errorf("Failed to get module base address: %v", err)
+	}
+
+	siblings := make(map[uint32]bool)
+	if procs, err := gameproc.List(prof.ProcessMatcher()); err == nil {
+		for _, p := range procs {
+			if p.PID != proc.PID {
+				siblings[p.PID] = true
+			}
+		}
+	}
+	return &attachment{pid: proc.PID, exe: proc.Exe, mem: mem, module: mod, siblings: siblings}, nil
+}
+
+// attachSelected attaches to the snapshot if one is given, otherwise to the
+// game instance chosen by selectProcess.
+func attachSelected(prof *profile.Profile, pid uint32, snapshot string, reader *bufio.Reader) (*attachment, error) {
+	if snapshot != "" {
+		snap, err := memory.LoadSnapshot(snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load snapshot: %v", err)
+		}
+		mod, err := memory.FindModule(snap, prof.ModuleName(snap.Process))
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("📸 Replaying snapshot", "process", snap.Process, "pid", snap.PID, "captured", snap.Captured.Format(time.DateTime))
+		return &attachment{pid: 0, exe: snap.Process, mem: snap, module: mod}, nil
+	}
+
+	proc, err := selectProcess(prof, pid, reader)
+	if err != nil {
+		return nil, err
+	}
+	return attachProcess(proc, prof)
+}
+
+// gameConn holds the current attachment, which is swapped out when the
+// client is restarted.
+type gameConn struct {
+	mu  sync.Mutex
+	cur *attachment
+}
+
+func (g *gameConn) current() *attachment {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cur
+}
+
+// openAttachment attaches to proc and resolves the unit chain.
+func openAttachment(proc gameproc.Process, prof *profile.Profile) (*attachment, error) {
+	a, err := attachProcess(proc, prof)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.resolveUnit(prof); err != nil {
+		a.close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// reattach waits for the client to come back and replaces the current
+// attachment. Instances that were already running when we attached belong
+// to someone else and are ignored. The pointer chain only resolves once the
+// game has finished booting, so failures are retried until stop is closed,
+// in which case it returns false and the old attachment stays current, so
+// the next writer notices the exit and reattaches in turn. The lock is only
+// held to swap the attachment, so current does not block while waiting.
+func (g *gameConn) reattach(prof *profile.Profile, stop chan struct{}) bool {
+	slog.Info("🔍 Waiting for game process to restart...")
+
+	old := g.current()
+	others := old.siblings
+
+	var lastErr string
+	for {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+
+		procs, _ := gameproc.List(prof.ProcessMatcher())
+		for _, p := range procs {
+			if others[p.PID] {
+				continue
+			}
+			a, err := openAttachment(p, prof)
+			if err == nil {
+				g.swap(old, a)
+				return true
+			}
+			if err.Error() != lastErr {
+				slog.Info("⏳ Game not ready yet", "pid", p.PID, "err", err)
+				lastErr = err.Error()
+			}
+		}
+		time.Sleep(time.Duration(prof.RetryInterval))
+	}
+}
+
+// swap makes a the current attachment in place of old. If another caller
+// has reattached meanwhile, its attachment is kept and a is closed.
+func (g *gameConn) swap(old, a *attachment) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cur != old {
+		a.close()
+		return
 	}
-	return h, nil
+	old.close()
+	g.cur = a
+	slog.Info("🟢 Reattached", "pid", a.pid, "target", fmt.Sprintf("0x%X", a.targetAddr))
 }
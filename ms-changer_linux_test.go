@@ -0,0 +1,403 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ms-changer/auditlog"
+	"ms-changer/gameproc"
+	"ms-changer/memory"
+	"ms-changer/profile"
+)
+
+// sim is a running tools/gamesim, driven through its stdin.
+type sim struct {
+	t       *testing.T
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+	profile *profile.Profile
+	file    string // the profile gamesim wrote
+	pids    []int  // every copy started, for cleanup
+}
+
+// startSim builds gamesim under a name of its own and starts it with a
+// profile that has a unit chain and a scene watch.
+func startSim(t *testing.T) *sim {
+	t.Helper()
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "gamesim-e2e")
+	build := exec.Command("go", "build", "-o", exe, "./tools/gamesim")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building gamesim: %v\n%s", err, out)
+	}
+	base := filepath.Join(dir, "base.json")
+	os.WriteFile(base, []byte(`{
+		"name": "e2e", "process": "game.exe", "retry_interval": "50ms",
+		"unit": {"base": "0x0", "offsets": ["0x10", "0x28", "0x8"]},
+		"watches": [{"name": "scene", "chain": {"base": "0x0", "offsets": ["0x30"]}, "apply_when": [3]}]
+	}`), 0644)
+	out := filepath.Join(dir, "sim.json")
+
+	s := &sim{t: t, lines: make(chan string, 100), file: out}
+	s.cmd = exec.Command(exe, "-profile", base, "-out", out)
+	s.cmd.Dir = dir
+	var err error
+	if s.stdin, err = s.cmd.StdinPipe(); err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.cmd.Stderr = s.cmd.Stdout
+	if err := s.cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	s.pids = append(s.pids, s.cmd.Process.Pid)
+	go func() {
+		sc := bufio.NewScanner(stdout)
+		for sc.Scan() {
+			s.lines <- sc.Text()
+		}
+		close(s.lines)
+	}()
+	t.Cleanup(func() {
+		s.stdin.Close()
+		for _, pid := range s.pids {
+			if p, err := os.FindProcess(pid); err == nil {
+				p.Kill()
+			}
+		}
+		s.cmd.Wait()
+	})
+
+	s.expect("🟢 gamesim ready")
+	if s.profile, err = profile.Load(out); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (s *sim) send(line string) {
+	s.t.Helper()
+	if _, err := fmt.Fprintln(s.stdin, line); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// expect waits for a line starting with prefix and returns it.
+func (s *sim) expect(prefix string) string {
+	s.t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("gamesim exited while waiting for %q", prefix)
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line
+			}
+		case <-timeout:
+			s.t.Fatalf("timed out waiting for %q", prefix)
+		}
+	}
+}
+
+// value asks gamesim for the unit value it holds.
+func (s *sim) value() string {
+	s.t.Helper()
+	s.send("value")
+	return strings.TrimPrefix(s.expect("value "), "value ")
+}
+
+// attach attaches to the only gamesim running, like the CLI does.
+func (s *sim) attach() *attachment {
+	s.t.Helper()
+	procs, err := gameproc.List(s.profile.ProcessMatcher())
+	if err != nil || len(procs) != 1 {
+		s.t.Fatalf("gameproc.List = %v, %v, want one gamesim", procs, err)
+	}
+	a, err := openAttachment(procs[0], s.profile)
+	if err != nil {
+		if strings.Contains(err.Error(), "permission denied") {
+			s.t.Skipf("cannot read other processes' memory here: %v", err)
+		}
+		s.t.Fatal(err)
+	}
+	return a
+}
+
+func TestResolveAndWrite(t *testing.T) {
+	s := startSim(t)
+	a := s.attach()
+	defer a.close()
+
+	if v, err := memory.ReadInt32(a.mem, a.targetAddr); err != nil || v != 1001001 {
+		t.Fatalf("unit at 0x%X = %d, %v, want 1001001", a.targetAddr, v, err)
+	}
+	e := memory.Explain(a.mem, a.module.Base, s.profile.Unit)
+	if !e.OK() {
+		t.Errorf("Explain reports the unit chain as broken: %+v", e)
+	}
+
+	entry, written := writeUnit(a, 1002001)
+	if !written || entry.Result != auditlog.ResultOK || entry.Previous != 1001001 || entry.PID != a.pid {
+		t.Errorf("writeUnit = %+v, %v", entry, written)
+	}
+	if got := s.value(); got != "1002001" {
+		t.Errorf("gamesim holds %s after the write, want 1002001", got)
+	}
+	if _, written := writeUnit(a, 1002001); written {
+		t.Error("writeUnit wrote a value that was already there")
+	}
+
+	// The game resets the unit; the next write puts ours back and records
+	// what the game had.
+	s.send("set 42")
+	if got := s.value(); got != "42" {
+		t.Fatalf("gamesim holds %s, want 42", got)
+	}
+	entry, written = writeUnit(a, 1002001)
+	if !written || entry.Result != auditlog.ResultOK || entry.Previous != 42 {
+		t.Errorf("writeUnit after reset = %+v, %v", entry, written)
+	}
+	if got := s.value(); got != "1002001" {
+		t.Errorf("gamesim holds %s, want 1002001", got)
+	}
+
+	// Watches gate the write
+	s.send("watch scene 1")
+	s.value() // wait for the command to be handled
+	if ok, blocking := s.profile.CanApply(a.readWatches(s.profile)); ok || blocking != "scene" {
+		t.Errorf("scene 1: CanApply = %v, %q, want blocked by scene", ok, blocking)
+	}
+	s.send("watch scene 3")
+	s.value()
+	if ok, _ := s.profile.CanApply(a.readWatches(s.profile)); !ok {
+		t.Error("scene 3: CanApply = false, want true")
+	}
+}
+
+// revertingProcess is a game that puts the old value back right after every
+// write, so the read-back sees something else.
+type revertingProcess struct {
+	memory.LiveProcess
+}
+
+func (p revertingProcess) Write(addr uintptr, buf []byte) error {
+	old := make([]byte, len(buf))
+	if err := p.LiveProcess.Read(addr, old); err != nil {
+		return err
+	}
+	if err := p.LiveProcess.Write(addr, buf); err != nil {
+		return err
+	}
+	return p.LiveProcess.Write(addr, old)
+}
+
+func TestWriteReverted(t *testing.T) {
+	s := startSim(t)
+	a := s.attach()
+	defer a.close()
+	a.mem = revertingProcess{a.mem.(memory.LiveProcess)}
+
+	entry, written := writeUnit(a, 1002001)
+	if !written || entry.Result != auditlog.ResultReverted {
+		t.Errorf("writeUnit = %+v, %v, want %s", entry, written, auditlog.ResultReverted)
+	}
+	if got := s.value(); got != "1001001" {
+		t.Errorf("gamesim holds %s, want the reverted 1001001", got)
+	}
+}
+
+func TestReattach(t *testing.T) {
+	s := startSim(t)
+	a := s.attach()
+	g := &gameConn{cur: a}
+	defer func() { g.current().close() }()
+
+	s.send("restart")
+	var pid int
+	if _, err := fmt.Sscanf(s.expect("🔁 Restarted as"), "🔁 Restarted as pid=%d", &pid); err != nil {
+		t.Fatal(err)
+	}
+	s.pids = append(s.pids, pid)
+	s.expect("🟢 gamesim ready")
+	s.cmd.Process.Wait() // reap the old copy; cmd.Wait would close the pipes the new one shares
+
+	if !a.exited() {
+		t.Fatal("the attachment did not notice the exit")
+	}
+	done := make(chan bool)
+	go func() { done <- g.reattach(s.profile, make(chan struct{})) }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("reattach returned false")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("reattach did not find the restarted game")
+	}
+
+	b := g.current()
+	if b == a || b.pid != uint32(pid) || b.exited() {
+		t.Fatalf("after reattach: pid %d, exited %v, want the new pid %d", b.pid, b.exited(), pid)
+	}
+	if !a.closed {
+		t.Error("the old attachment was not closed")
+	}
+	if entry, written := writeUnit(b, 1003001); !written || entry.Result != auditlog.ResultOK {
+		t.Errorf("writeUnit after reattach = %+v, %v", entry, written)
+	}
+	if got := s.value(); got != "1003001" {
+		t.Errorf("restarted gamesim holds %s, want 1003001", got)
+	}
+}
+
+// TestReattachStopped stops the writer while it waits for the game to come
+// back: the old attachment stays current, so the next writer reattaches.
+func TestReattachStopped(t *testing.T) {
+	s := startSim(t)
+	a := s.attach()
+	g := &gameConn{cur: a}
+	defer func() { g.current().close() }()
+
+	s.send("exit")
+	s.cmd.Wait()
+	stop := make(chan struct{})
+	done := make(chan bool)
+	go func() { done <- g.reattach(s.profile, stop) }()
+
+	// Readers are not held up while reattach waits for the game.
+	time.Sleep(100 * time.Millisecond)
+	got := make(chan *attachment)
+	go func() { got <- g.current() }()
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("current blocked while reattach was waiting")
+	}
+
+	close(stop)
+	if <-done {
+		t.Fatal("reattach returned true without a game")
+	}
+	if g.current() != a || !a.exited() {
+		t.Error("after a stopped reattach the exited attachment should stay current")
+	}
+	a.close()
+	a.close() // the deferred close must not fail either
+}
+
+// buildGUICLI builds ms-changer-gui-cli.go for this platform: it only uses
+// the memory package, so without its build constraint it runs on the /proc
+// backend too.
+func buildGUICLI(t *testing.T) string {
+	t.Helper()
+	src, err := os.ReadFile("ms-changer-gui-cli.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(src), "\n") {
+		if !strings.HasPrefix(line, "//go:build") && !strings.HasPrefix(line, "// +build") {
+			lines = append(lines, line)
+		}
+	}
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(main, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(dir, "gui-cli")
+	if out, err := exec.Command("go", "build", "-o", exe, main).CombinedOutput(); err != nil {
+		t.Fatalf("building the GUI CLI: %v\n%s", err, out)
+	}
+	return exe
+}
+
+// runGUICLI runs the GUI CLI like the GUI does and returns the result its
+// last record reports.
+func runGUICLI(t *testing.T, exe string, args ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	cmd := exec.Command(exe, append([]string{"-log-format", "json"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HOME="+dir, "XDG_CONFIG_HOME="+dir)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &rec); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, out)
+	}
+	result, _ := rec["result"].(string)
+	return result
+}
+
+func TestGUICLI(t *testing.T) {
+	s := startSim(t)
+	a := s.attach() // skips where other processes' memory cannot be read
+	defer a.close()
+	exe := buildGUICLI(t)
+	pid := fmt.Sprint(a.pid)
+
+	s.send("watch scene 1")
+	if got := runGUICLI(t, exe, "-pid", pid, "-profile", s.file, "1002001"); got != "queued" {
+		t.Errorf("outside the scene: result %q, want queued", got)
+	}
+	if got := s.value(); got != "1001001" {
+		t.Errorf("a queued write changed the unit to %s", got)
+	}
+	s.send("watch scene 3")
+	s.value()
+	if got := runGUICLI(t, exe, "-pid", pid, "-profile", s.file, "1002001"); got != auditlog.ResultOK {
+		t.Errorf("in the scene: result %q, want %s", got, auditlog.ResultOK)
+	}
+	if got := s.value(); got != "1002001" {
+		t.Errorf("gamesim holds %s, want 1002001", got)
+	}
+	if got := runGUICLI(t, exe, "-pid", pid, "-profile", s.file, "1002001"); got != "unchanged" {
+		t.Errorf("second write: result %q, want unchanged", got)
+	}
+
+	// Replay against a snapshot of the game: the write only changes the
+	// snapshot.
+	chains := []profile.Chain{s.profile.Unit}
+	for _, w := range s.profile.Watches {
+		chains = append(chains, w.Chain)
+	}
+	snap, err := memory.Capture(a.mem, a.module.Base, chains, 0x40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap.PID, snap.Process = a.pid, a.exe
+	file := filepath.Join(t.TempDir(), "snap.json.gz")
+	if err := snap.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGUICLI(t, exe, "-snapshot", file, "-profile", s.file, "1003001"); got != auditlog.ResultOK {
+		t.Errorf("snapshot: result %q, want %s", got, auditlog.ResultOK)
+	}
+	if got := runGUICLI(t, exe, "-snapshot", file, "-profile", s.file, "1002001"); got != "unchanged" {
+		t.Errorf("snapshot: result %q, want unchanged", got)
+	}
+	if got := s.value(); got != "1002001" {
+		t.Errorf("the snapshot write reached the game: it holds %s", got)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"ms-changer/auditlog"
+	"ms-changer/memory"
+	"ms-changer/profile"
+)
+
+// Layout of the snapshot game: module+0x10 points to the heap, where the
+// unit is at +0x4 and the scene at +0x8.
+const (
+	snapModule = 0x400000
+	snapHeap   = 0x800000
+	snapUnit   = snapHeap + 0x4
+	snapScene  = snapHeap + 0x8
+)
+
+// snapshotGame returns an attachment to a snapshot holding unit in scene,
+// and a profile that only writes in scene 3.
+func snapshotGame(t *testing.T, unit, scene int32) (*attachment, *profile.Profile) {
+	t.Helper()
+	mod := make([]byte, 0x20)
+	binary.LittleEndian.PutUint64(mod[0x10:], snapHeap)
+	heap := make([]byte, 0x10)
+	binary.LittleEndian.PutUint32(heap[0x4:], uint32(unit))
+	binary.LittleEndian.PutUint32(heap[0x8:], uint32(scene))
+	snap := &memory.Snapshot{
+		Version:    1,
+		Process:    "game.exe",
+		ModuleList: []memory.Module{{Name: "game.exe", Base: snapModule, Size: 0x20}},
+		Blocks:     []memory.Block{{Addr: snapModule, Data: mod}, {Addr: snapHeap, Data: heap}},
+	}
+	prof := &profile.Profile{
+		Name: "test",
+		Unit: profile.Chain{Base: 0x10, Offsets: []profile.Addr{0x4}},
+		Watches: []profile.Watch{
+			{Name: "scene", Chain: profile.Chain{Base: 0x10, Offsets: []profile.Addr{0x8}}, ApplyWhen: []int32{3}},
+		},
+	}
+	a := &attachment{exe: "game.exe", mem: snap, module: snap.ModuleList[0]}
+	if err := a.resolveUnit(prof); err != nil {
+		t.Fatal(err)
+	}
+	return a, prof
+}
+
+// set writes v at addr in the snapshot, as the game would.
+func set(t *testing.T, a *attachment, addr uintptr, v int32) {
+	t.Helper()
+	if err := memory.WriteInt32(a.mem, addr, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriterStep(t *testing.T) {
+	a, prof := snapshotGame(t, 1001001, 1)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := auditlog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &writer{prof: prof, unit: Unit{ID: 2, MS: "シャア専用ゲルググ", Value: 1002001}, audit: audit}
+
+	for _, step := range []struct {
+		name    string
+		scene   int32
+		game    int32 // unit the game sets before the step, 0 = unchanged
+		holds   bool
+		waiting bool
+		unit    int32
+	}{
+		{"title screen: queued", 1, 0, false, true, 1001001},
+		{"still outside", 2, 0, false, true, 1001001},
+		{"unit select: applied", 3, 0, true, false, 1002001},
+		{"already set", 3, 0, true, false, 1002001},
+		{"battle: the game resets the unit, queued again", 5, 1003001, false, true, 1003001},
+		{"back in unit select: applied again", 3, 0, true, false, 1002001},
+	} {
+		set(t, a, snapScene, step.scene)
+		if step.game != 0 {
+			set(t, a, snapUnit, step.game)
+		}
+		holds := w.step(a)
+		unit, _ := memory.ReadInt32(a.mem, snapUnit)
+		if holds != step.holds || w.waiting != step.waiting || unit != step.unit {
+			t.Errorf("%s: step = %v, waiting %v, game holds %d; want %v, %v, %d",
+				step.name, holds, w.waiting, unit, step.holds, step.waiting, step.unit)
+		}
+	}
+	if w.original == nil || *w.original != 1001001 {
+		t.Errorf("original = %v, want the unit before the first write, 1001001", w.original)
+	}
+
+	entries, err := auditlog.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Previous != 1001001 || entries[1].Previous != 1003001 {
+		t.Fatalf("audit log = %v, want the two writes", entries)
+	}
+	if e := entries[0]; e.Result != auditlog.ResultOK || e.UnitID != 2 || e.Profile != "test" {
+		t.Errorf("audit entry = %+v", e)
+	}
+
+	set(t, a, snapUnit, 1004001)
+	w.restore(a)
+	if unit, _ := memory.ReadInt32(a.mem, snapUnit); unit != 1001001 {
+		t.Errorf("after restore the game holds %d, want 1001001", unit)
+	}
+}
+
+func TestWriterStepUngated(t *testing.T) {
+	a, prof := snapshotGame(t, 0, 1)
+	prof.Watches[0].ApplyWhen = nil // read for display only
+	w := &writer{prof: prof, unit: Unit{Value: 1002001}}
+	if !w.step(a) || w.waiting {
+		t.Error("a watch without apply_when held the write back")
+	}
+	if w.original != nil {
+		t.Errorf("original = %d, want none: the game held no unit", *w.original)
+	}
+	w.restore(a) // nothing to restore
+	if unit, _ := memory.ReadInt32(a.mem, snapUnit); unit != 1002001 {
+		t.Errorf("game holds %d, want 1002001", unit)
+	}
+}
+
+func TestWriterStepFailing(t *testing.T) {
+	a, prof := snapshotGame(t, 1001001, 3)
+	a.targetAddr = 0x900000 // not in the snapshot
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := auditlog.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &writer{prof: prof, unit: Unit{Value: 1002001}, audit: audit}
+	for range 3 {
+		if w.step(a) {
+			t.Fatal("step reported a failed write as holding")
+		}
+	}
+	entries, _ := auditlog.Read(path)
+	if len(entries) != 1 || entries[0].Result != auditlog.ResultFailed {
+		t.Errorf("audit log = %v, want the failure once", entries)
+	}
+}
+
+func TestWriterReattached(t *testing.T) {
+	a, prof := snapshotGame(t, 1001001, 3)
+	w := &writer{prof: prof, unit: Unit{Value: 1002001}}
+	w.step(a)
+
+	// The game restarts with another unit selected.
+	b, _ := snapshotGame(t, 1005001, 3)
+	w.reattached()
+	if !w.step(b) {
+		t.Fatal("step on the new game did not write")
+	}
+	w.restore(b)
+	if unit, _ := memory.ReadInt32(b.mem, snapUnit); unit != 1005001 {
+		t.Errorf("restore wrote %d into the new game, want its own 1005001", unit)
+	}
+}
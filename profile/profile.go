@@ -0,0 +1,254 @@
+// Package profile describes where in the game client the unit value and the
+// optional watch values (scene/state ids) live. Profiles are read from a JSON
+// file; when none is present the built-in chain from the CE pointer scan is used.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Addr is an address or offset that accepts both "0x..." strings and plain
+// numbers in JSON.
+type Addr uintptr
+
+func (a *Addr) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n uint64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid address %s", data)
+		}
+		*a = Addr(n)
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", s, err)
+	}
+	*a = Addr(n)
+	return nil
+}
+
+func (a Addr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fmt.Sprintf("0x%X", uintptr(a)))
+}
+
+// Duration is a time.Duration written as "2s", "500ms", ... in JSON.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Chain is a pointer chain: Base is relative to the module base, each offset
+// is added after dereferencing the previous address.
+type Chain struct {
+	Base    Addr   `json:"base"`
+	Offsets []Addr `json:"offsets"`
+}
+
+// Watch is an additional int32 value read from the game. When ApplyWhen is
+// not empty, the unit is only written while the watch holds one of those values.
+type Watch struct {
+	Name      string  `json:"name"`
+	Chain     Chain   `json:"chain"`
+	ApplyWhen []int32 `json:"apply_when,omitempty"`
+}
+
+type Profile struct {
+	Name string `json:"name"`
+
+	// Process is the executable to attach to: a plain name, a glob such as
+	// "vsac27_*.exe" or a regular expression prefixed with "re:".
+	Process string `json:"process"`
+	// Module holds the chain base. Empty means the matched executable.
+	Module string `json:"module,omitempty"`
+	// RetryInterval is how long to wait between attempts to find the game.
+	RetryInterval Duration `json:"retry_interval"`
+
+	Unit    Chain   `json:"unit"`
+	Watches []Watch `json:"watches,omitempty"`
+}
+
+// Default returns the chain from the CE screenshot with no watches.
+func Default() *Profile {
+	return &Profile{
+		Name:          "default",
+		Process:       "vsac27_Release_CLIENT.exe",
+		RetryInterval: Duration(2 * time.Second),
+		Unit: Chain{
+			Base:    0x020023B8,
+			Offsets: []Addr{0x4A0, 0x108, 0x440, 0x188, 0x38, 0x534},
+		},
+	}
+}
+
+// Load reads a profile from filename. A missing file is not an error and
+// yields Default().
+func Load(filename string) (*Profile, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		slog.Debug("no profile file, using built-in chain", "file", filename)
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data, filename)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("profile loaded", "file", filename, "name", p.Name, "watches", len(p.Watches))
+	return p, nil
+}
+
+// Parse reads a profile from data, filling in what it leaves out from
+// Default(), and validates it. name is used in errors.
+func Parse(data []byte, name string) (*Profile, error) {
+	p := Default()
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return p, nil
+}
+
+// Validate checks the fields a profile cannot work without. It is called by
+// Load and should be called again after applying command line overrides.
+func (p *Profile) Validate() error {
+	if len(p.Unit.Offsets) == 0 {
+		return fmt.Errorf("unit chain has no offsets")
+	}
+	if _, err := Matcher(p.Process); err != nil {
+		return err
+	}
+	if p.RetryInterval <= 0 {
+		return fmt.Errorf("retry_interval must be positive")
+	}
+	for _, w := range p.Watches {
+		if w.Name == "" {
+			return fmt.Errorf("watch without name")
+		}
+	}
+	return nil
+}
+
+// Override applies command line overrides; empty or zero values keep the
+// profile's setting. The result is validated.
+func (p *Profile) Override(process, module string, retry time.Duration) error {
+	if process != "" {
+		p.Process = process
+	}
+	if module != "" {
+		p.Module = module
+	}
+	if retry != 0 {
+		p.RetryInterval = Duration(retry)
+	}
+	return p.Validate()
+}
+
+// ModuleName returns the module holding the chain base for a process whose
+// executable is exe.
+func (p *Profile) ModuleName(exe string) string {
+	if p.Module != "" {
+		return p.Module
+	}
+	return exe
+}
+
+// Matcher returns a case-insensitive matcher for an executable name pattern:
+// "re:<regexp>", a glob such as "vsac27_*.exe", or a plain name.
+func Matcher(pattern string) (func(string) bool, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("process name is empty")
+	}
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process regexp %q: %v", expr, err)
+		}
+		return re.MatchString, nil
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		glob := strings.ToLower(pattern)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid process glob %q: %v", pattern, err)
+		}
+		return func(name string) bool {
+			ok, _ := path.Match(glob, strings.ToLower(name))
+			return ok
+		}, nil
+	}
+	return func(name string) bool {
+		return strings.EqualFold(name, pattern)
+	}, nil
+}
+
+// ProcessMatcher returns the matcher for p.Process. It only fails on
+// profiles that have not been validated.
+func (p *Profile) ProcessMatcher() func(string) bool {
+	match, err := Matcher(p.Process)
+	if err != nil {
+		return func(string) bool { return false }
+	}
+	return match
+}
+
+// Gated reports whether any watch restricts when the unit may be written.
+func (p *Profile) Gated() bool {
+	for _, w := range p.Watches {
+		if len(w.ApplyWhen) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CanApply checks the current watch values against the apply policy. It
+// returns false and the name of the first blocking watch when writing
+// should be held back. A gated watch missing from values blocks.
+func (p *Profile) CanApply(values map[string]int32) (bool, string) {
+	for _, w := range p.Watches {
+		if len(w.ApplyWhen) == 0 {
+			continue
+		}
+		v, ok := values[w.Name]
+		if !ok || !contains(w.ApplyWhen, v) {
+			return false, w.Name
+		}
+	}
+	return true, ""
+}
+
+func contains(list []int32, v int32) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package profile_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"ms-changer/memory"
+	"ms-changer/profile"
+)
+
+func TestGated(t *testing.T) {
+	tests := []struct {
+		name    string
+		watches []profile.Watch
+		want    bool
+	}{
+		{"no watches", nil, false},
+		{"watch without policy", []profile.Watch{{Name: "scene"}}, false},
+		{"gated watch", []profile.Watch{{Name: "state"}, {Name: "scene", ApplyWhen: []int32{3}}}, true},
+	}
+	for _, tt := range tests {
+		p := &profile.Profile{Watches: tt.watches}
+		if got := p.Gated(); got != tt.want {
+			t.Errorf("%s: Gated() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanApply(t *testing.T) {
+	p := &profile.Profile{Watches: []profile.Watch{
+		{Name: "scene", ApplyWhen: []int32{3, 4}},
+		{Name: "mode"}, // read for display only
+		{Name: "state", ApplyWhen: []int32{1}},
+	}}
+	tests := []struct {
+		name         string
+		values       map[string]int32
+		wantOK       bool
+		wantBlocking string
+	}{
+		{"outside the scene", map[string]int32{"scene": 1, "state": 1}, false, "scene"},
+		{"in the scene", map[string]int32{"scene": 3, "state": 1}, true, ""},
+		{"other allowed scene", map[string]int32{"scene": 4, "state": 1, "mode": 9}, true, ""},
+		{"second watch blocks", map[string]int32{"scene": 3, "state": 0}, false, "state"},
+		{"both block, first reported", map[string]int32{"scene": 0, "state": 0}, false, "scene"},
+		{"unreadable watch blocks", map[string]int32{"scene": 3}, false, "state"},
+		{"nothing read", nil, false, "scene"},
+	}
+	for _, tt := range tests {
+		ok, blocking := p.CanApply(tt.values)
+		if ok != tt.wantOK || blocking != tt.wantBlocking {
+			t.Errorf("%s: CanApply(%v) = %v, %q, want %v, %q", tt.name, tt.values, ok, blocking, tt.wantOK, tt.wantBlocking)
+		}
+	}
+}
+
+// TestCanApplySceneChange follows a write held back by the scene watch
+// through a fake game: queued while outside the allowed scene, applied once
+// the game enters it.
+func TestCanApplySceneChange(t *testing.T) {
+	const (
+		moduleBase = 0x400000
+		heap       = 0x800000
+	)
+	// module+0x10 -> heap, scene at heap+0x8
+	mod := make([]byte, 0x20)
+	binary.LittleEndian.PutUint64(mod[0x10:], heap)
+	snap := &memory.Snapshot{
+		Version:    1,
+		ModuleList: []memory.Module{{Name: "game.exe", Base: moduleBase, Size: 0x20}},
+		Blocks: []memory.Block{
+			{Addr: moduleBase, Data: mod},
+			{Addr: heap, Data: make([]byte, 0x10)},
+		},
+	}
+	p := &profile.Profile{Watches: []profile.Watch{
+		{Name: "scene", Chain: profile.Chain{Base: 0x10, Offsets: []profile.Addr{0x8}}, ApplyWhen: []int32{3}},
+	}}
+	read := func() map[string]int32 {
+		values := map[string]int32{}
+		for _, w := range p.Watches {
+			addr, err := memory.Resolve(snap, moduleBase, w.Chain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, err := memory.ReadInt32(snap, addr); err == nil {
+				values[w.Name] = v
+			}
+		}
+		return values
+	}
+
+	for _, step := range []struct {
+		scene int32
+		want  bool
+	}{
+		{1, false}, // title screen: queued
+		{2, false},
+		{3, true}, // unit select: applied
+		{5, false},
+	} {
+		if err := memory.WriteInt32(snap, heap+0x8, step.scene); err != nil {
+			t.Fatal(err)
+		}
+		if ok, blocking := p.CanApply(read()); ok != step.want {
+			t.Errorf("scene %d: CanApply = %v (blocking %q), want %v", step.scene, ok, blocking, step.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package settings holds the user's preferences, stored as JSON in the
+// per-user config directory (e.g. %AppData%\ms-changer\settings.json).
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ms-changer/profile"
+)
+
+// Freeze strategies: keep writing the unit every interval, or write it
+// once and stop.
+const (
+	FreezeContinuous = "continuous"
+	FreezeOnce       = "once"
+)
+
+// Languages the UI can be shown in.
+var Languages = []string{"en", "ja"}
+
+// Hotkey actions.
+var Actions = []string{"start", "stop", "palette"}
+
+type Settings struct {
+	UnitsFile      string            `json:"units_file"`
+	Overrides      []string          `json:"overrides"` // merged over units_file in order, e.g. team then personal
+	Profile        string            `json:"profile"`
+	WriteInterval  profile.Duration  `json:"write_interval"`
+	FreezeStrategy string            `json:"freeze_strategy"`
+	RestoreOnStop  bool              `json:"restore_on_stop"`
+	CloseToTray    bool              `json:"close_to_tray"` // closing the GUI window hides it in the tray
+	Language       string            `json:"language"`
+	Hotkeys        map[string]string `json:"hotkeys"`      // action -> shortcut, e.g. "Ctrl+Return"
+	ServerPort     int               `json:"server_port"`  // GUI commands over TCP on 127.0.0.1, 0 = disabled
+	TrustedKeys    []string          `json:"trusted_keys"` // base64 ed25519 keys bundles must be signed with
+	Favorites      []int32           `json:"favorites"`    // unit values listed first in the tray menu
+}
+
+// Default returns the settings used when no settings file exists.
+func Default() *Settings {
+	return &Settings{
+		UnitsFile:      "units.csv",
+		Profile:        "profile.json",
+		WriteInterval:  profile.Duration(time.Second),
+		FreezeStrategy: FreezeContinuous,
+		CloseToTray:    true,
+		Language:       "en",
+		Hotkeys: map[string]string{
+			"start":   "Ctrl+Return",
+			"stop":    "Ctrl+Backspace",
+			"palette": "Ctrl+K",
+		},
+	}
+}
+
+// Path returns the settings file in the user's config directory.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ms-changer", "settings.json"), nil
+}
+
+// Load reads the settings file. A missing file yields the defaults; fields
+// missing from the file keep their default values.
+func Load(filename string) (*Settings, error) {
+	s := Default()
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return s, nil
+}
+
+// Save writes the settings through a temporary file so a crash never
+// leaves a half-written file behind.
+func (s *Settings) Save(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+func (s *Settings) Validate() error {
+	if s.UnitsFile == "" {
+		return fmt.Errorf("units_file must not be empty")
+	}
+	if s.Profile == "" {
+		return fmt.Errorf("profile must not be empty")
+	}
+	if s.WriteInterval < profile.Duration(100*time.Millisecond) {
+		return fmt.Errorf("write_interval must be at least 100ms")
+	}
+	if s.FreezeStrategy != FreezeContinuous && s.FreezeStrategy != FreezeOnce {
+		return fmt.Errorf("freeze_strategy must be %q or %q", FreezeContinuous, FreezeOnce)
+	}
+	if !contains(Languages, s.Language) {
+		return fmt.Errorf("language must be one of %s", strings.Join(Languages, ", "))
+	}
+	for action, shortcut := range s.Hotkeys {
+		if !contains(Actions, action) {
+			return fmt.Errorf("unknown hotkey action %q", action)
+		}
+		if shortcut == "" {
+			continue
+		}
+		if _, _, err := ParseShortcut(shortcut); err != nil {
+			return fmt.Errorf("hotkey %s: %v", action, err)
+		}
+	}
+	if s.ServerPort != 0 && (s.ServerPort < 1024 || s.ServerPort > 65535) {
+		return fmt.Errorf("server_port must be 0 or between 1024 and 65535")
+	}
+	return nil
+}
+
+// ParseShortcut splits a shortcut such as "Ctrl+Shift+S" into its modifiers
+// (Ctrl, Alt, Shift, Super) and key name.
+func ParseShortcut(s string) (modifiers []string, key string, err error) {
+	parts := strings.Split(s, "+")
+	key = strings.TrimSpace(parts[len(parts)-1])
+	if key == "" {
+		return nil, "", fmt.Errorf("invalid shortcut %q", s)
+	}
+	for _, p := range parts[:len(parts)-1] {
+		switch m := strings.TrimSpace(p); strings.ToLower(m) {
+		case "ctrl", "alt", "shift", "super":
+			modifiers = append(modifiers, strings.ToUpper(m[:1])+strings.ToLower(m[1:]))
+		default:
+			return nil, "", fmt.Errorf("unknown modifier %q in %q", m, s)
+		}
+	}
+	if len(modifiers) == 0 {
+		return nil, "", fmt.Errorf("shortcut %q needs a modifier", s)
+	}
+	return modifiers, key, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"ms-changer/profile"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Load(filepath.Join(dir, "missing.json"))
+	if err != nil || !reflect.DeepEqual(s, Default()) {
+		t.Errorf("Load of a missing file = %+v, %v, want the defaults", s, err)
+	}
+
+	// Fields missing from the file keep their defaults.
+	path := filepath.Join(dir, "settings.json")
+	os.WriteFile(path, []byte(`{"language": "ja", "server_port": 8765}`), 0644)
+	s, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Language != "ja" || s.ServerPort != 8765 || s.UnitsFile != "units.csv" || s.Hotkeys["start"] != "Ctrl+Return" {
+		t.Errorf("Load = %+v", s)
+	}
+
+	os.WriteFile(path, []byte(`{"server_port": 80}`), 0644)
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "server_port") {
+		t.Errorf("Load of an invalid file = %v, want the server_port error", err)
+	}
+	os.WriteFile(path, []byte(`{"language": `), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted broken JSON")
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ms-changer", "settings.json")
+	s := Default()
+	s.Overrides = []string{"team.csv"}
+	s.WriteInterval = profile.Duration(500 * time.Millisecond)
+	s.ServerPort = 8765
+	s.Favorites = []int32{1001001}
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, s) {
+		t.Errorf("Load after Save = %+v, want %+v", back, s)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Save left its temporary file behind")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*Settings)
+		wantErr string
+	}{
+		{"defaults", func(*Settings) {}, ""},
+		{"no units file", func(s *Settings) { s.UnitsFile = "" }, "units_file"},
+		{"no profile", func(s *Settings) { s.Profile = "" }, "profile"},
+		{"interval too short", func(s *Settings) { s.WriteInterval = profile.Duration(10 * time.Millisecond) }, "write_interval"},
+		{"unknown strategy", func(s *Settings) { s.FreezeStrategy = "sometimes" }, "freeze_strategy"},
+		{"unknown language", func(s *Settings) { s.Language = "fr" }, "language"},
+		{"unknown action", func(s *Settings) { s.Hotkeys["jump"] = "Ctrl+J" }, "unknown hotkey action"},
+		{"hotkey without modifier", func(s *Settings) { s.Hotkeys["stop"] = "Escape" }, "needs a modifier"},
+		{"hotkey turned off", func(s *Settings) { s.Hotkeys["palette"] = "" }, ""},
+		{"server port", func(s *Settings) { s.ServerPort = 8765 }, ""},
+		{"privileged server port", func(s *Settings) { s.ServerPort = 80 }, "server_port"},
+		{"server port out of range", func(s *Settings) { s.ServerPort = 70000 }, "server_port"},
+	}
+	for _, tt := range tests {
+		s := Default()
+		tt.change(s)
+		err := s.Validate()
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: Validate = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseShortcut(t *testing.T) {
+	mods, key, err := ParseShortcut("ctrl+Shift+S")
+	if err != nil || !reflect.DeepEqual(mods, []string{"Ctrl", "Shift"}) || key != "S" {
+		t.Errorf("ParseShortcut = %v, %q, %v", mods, key, err)
+	}
+	for _, bad := range []string{"Ctrl+", "Hyper+K", "K"} {
+		if _, _, err := ParseShortcut(bad); err == nil {
+			t.Errorf("ParseShortcut accepted %q", bad)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+//go:build linux
+// +build linux
+
+// Command gamesim stands in for the game client so ms-changer can be driven
+// end to end on Linux through the /proc backend. It lays out the pointer
+// chains of a profile in its own memory, writes a profile that points at
+// them and then runs commands read from stdin, one per line:
+//
+//	value              print the unit value
+//	set <value>        overwrite the unit value, like the game resetting it
+//	watch <name> <v>   set a watch value, e.g. the scene id
+//	sleep <duration>   pause a script, e.g. "sleep 2s"
+//	restart            start a fresh copy of itself and exit
+//	exit [code]        exit
+//
+// Example:
+//
+//	go build -o gamesim ./tools/gamesim
+//	./gamesim -out sim-profile.json < script.txt &
+//	go run ms-changer.go -profile sim-profile.json read
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"ms-changer/memory"
+	"ms-changer/profile"
+)
+
+// anchors hold the first pointer of every chain. They are initialised so the
+// linker places them in the file-backed data segment, i.e. inside the
+// executable's module like the game's static base pointers.
+var anchors = [16]uintptr{0x5EED}
+
+// objects keeps the simulated heap alive; the chains store raw addresses
+// into these slices, which the garbage collector does not move.
+var objects [][]byte
+
+// chain is a pointer chain laid out in memory, ending at value.
+type chain struct {
+	value []byte // 4 bytes holding the int32 at the end of the chain
+}
+
+func (c chain) get() int32 {
+	return int32(binary.LittleEndian.Uint32(c.value))
+}
+
+func (c chain) set(v int32) {
+	binary.LittleEndian.PutUint32(c.value, uint32(v))
+}
+
+// build lays out offsets starting at anchors[slot].
+func build(slot int, offsets []profile.Addr) chain {
+	prev := &anchors[slot]
+	var last []byte
+	for _, off := range offsets {
+		obj := make([]byte, uintptr(off)+8)
+		objects = append(objects, obj)
+		*prev = uintptr(unsafe.Pointer(&obj[0]))
+		prev = (*uintptr)(unsafe.Pointer(&obj[off]))
+		last = obj[off : off+4]
+	}
+	return chain{value: last}
+}
+
+func main() {
+	profileFlag := flag.String("profile", "profile.json", "profile whose chains are simulated")
+	out := flag.String("out", "sim-profile.json", "where to write the profile for this process")
+	value := flag.Int("value", 1001001, "initial unit value")
+	flag.Parse()
+
+	base, err := profile.Load(*profileFlag)
+	if err != nil {
+		fmt.Println("❌ Failed to load profile:", err)
+		os.Exit(1)
+	}
+	if len(base.Watches)+1 > len(anchors) {
+		fmt.Printf("❌ At most %d watches are supported\n", len(anchors)-1)
+		os.Exit(1)
+	}
+
+	exe, _ := os.Executable()
+	exeName := filepath.Base(exe)
+	self, err := memory.OpenProcess(uint32(os.Getpid()))
+	if err != nil {
+		fmt.Println("❌", err)
+		os.Exit(1)
+	}
+	mod, err := memory.FindModule(self, exeName)
+	self.Close()
+	if err != nil {
+		fmt.Println("❌", err)
+		os.Exit(1)
+	}
+	rva := func(slot int) profile.Addr {
+		return profile.Addr(uintptr(unsafe.Pointer(&anchors[slot])) - mod.Base)
+	}
+
+	sim := &profile.Profile{
+		Name:          "gamesim",
+		Process:       exeName,
+		RetryInterval: base.RetryInterval,
+		Unit:          profile.Chain{Base: rva(0), Offsets: base.Unit.Offsets},
+	}
+	unit := build(0, base.Unit.Offsets)
+	unit.set(int32(*value))
+
+	watches := make(map[string]chain)
+	for i, w := range base.Watches {
+		watches[w.Name] = build(i+1, w.Chain.Offsets)
+		w.Chain = profile.Chain{Base: rva(i + 1), Offsets: w.Chain.Offsets}
+		sim.Watches = append(sim.Watches, w)
+	}
+
+	data, _ := json.MarshalIndent(sim, "", "  ")
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fmt.Println("❌ Failed to write profile:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🟢 gamesim ready: pid=%d module=%s base=0x%X profile=%s\n", os.Getpid(), exeName, mod.Base, *out)
+
+	for {
+		line, err := readLine()
+		if err != nil {
+			break
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 || strings.HasPrefix(args[0], "#") {
+			continue
+		}
+		switch {
+		case args[0] == "value":
+			fmt.Println("value", unit.get())
+		case args[0] == "set" && len(args) == 2:
+			v, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Println("❌ Invalid value:", args[1])
+				continue
+			}
+			unit.set(int32(v))
+		case args[0] == "watch" && len(args) == 3:
+			w, ok := watches[args[1]]
+			v, err := strconv.Atoi(args[2])
+			if !ok || err != nil {
+				fmt.Println("❌ Unknown watch or invalid value:", args[1], args[2])
+				continue
+			}
+			w.set(int32(v))
+		case args[0] == "sleep" && len(args) == 2:
+			d, err := time.ParseDuration(args[1])
+			if err != nil {
+				fmt.Println("❌ Invalid duration:", args[1])
+				continue
+			}
+			time.Sleep(d)
+		case args[0] == "restart":
+			cmd := exec.Command(exe, os.Args[1:]...)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			if err := cmd.Start(); err != nil {
+				fmt.Println("❌ Restart failed:", err)
+				continue
+			}
+			fmt.Printf("🔁 Restarted as pid=%d\n", cmd.Process.Pid)
+			os.Exit(0)
+		case args[0] == "exit":
+			code := 0
+			if len(args) == 2 {
+				code, _ = strconv.Atoi(args[1])
+			}
+			os.Exit(code)
+		default:
+			fmt.Println("❌ Unknown command:", line)
+		}
+	}
+	// Keep running once the script is done so the tools can attach.
+	for {
+		time.Sleep(time.Hour)
+	}
+}
+
+// readLine reads stdin a byte at a time so nothing past the current line is
+// consumed; after a restart the new copy continues with the next command.
+func readLine() (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := os.Stdin.Read(b[:])
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			if len(line) > 0 {
+				return string(line), nil
+			}
+			return "", err
+		}
+	}
+}
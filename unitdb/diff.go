@@ -0,0 +1,138 @@
+package unitdb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kinds of differences between two databases.
+const (
+	Added    = "added"
+	Removed  = "removed"
+	Renamed  = "renamed"  // same value, other title or name
+	Revalued = "revalued" // same id, other value
+	Changed  = "changed"  // same value and name, other metadata
+)
+
+// Difference is one unit that differs between two databases. Old is nil
+// for added units and New for removed ones.
+type Difference struct {
+	Kind string
+	Old  *Unit
+	New  *Unit
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case Added:
+		return fmt.Sprintf("+ %d %s / %s (%d)", d.New.ID, d.New.Title, d.New.MS, d.New.Value)
+	case Removed:
+		return fmt.Sprintf("- %d %s / %s (%d)", d.Old.ID, d.Old.Title, d.Old.MS, d.Old.Value)
+	case Renamed:
+		return fmt.Sprintf("~ %d: %s / %s -> %s / %s", d.New.Value, d.Old.Title, d.Old.MS, d.New.Title, d.New.MS)
+	case Revalued:
+		return fmt.Sprintf("~ %d %s: value %d -> %d", d.New.ID, d.New.MS, d.Old.Value, d.New.Value)
+	}
+	return fmt.Sprintf("~ %d %s: %s changed", d.New.Value, d.New.MS, strings.Join(changedFields(d.Old, d.New), ", "))
+}
+
+// Diff compares two databases. Units are matched by id first, as long as
+// they kept their name or their value, so two units that swap values are
+// reported as revalued rather than renamed. Units left over are matched by
+// value, which catches units that only got a new id. The result is in the
+// order of new, followed by the removed units in the order of old.
+func Diff(old, new []Unit) []Difference {
+	oldByID := map[int32]*Unit{}
+	for i := range old {
+		if _, ok := oldByID[old[i].ID]; !ok {
+			oldByID[old[i].ID] = &old[i]
+		}
+	}
+	pair := map[*Unit]*Unit{} // new -> old
+	matched := map[*Unit]bool{}
+	for i := range new {
+		n := &new[i]
+		if o := oldByID[n.ID]; o != nil && !matched[o] && (sameName(o, n) || o.Value == n.Value) {
+			pair[n], matched[o] = o, true
+		}
+	}
+	oldByValue := map[int32]*Unit{}
+	for i := range old {
+		if o := &old[i]; !matched[o] {
+			if _, ok := oldByValue[o.Value]; !ok {
+				oldByValue[o.Value] = o
+			}
+		}
+	}
+	for i := range new {
+		n := &new[i]
+		if o := oldByValue[n.Value]; pair[n] == nil && o != nil && !matched[o] {
+			pair[n], matched[o] = o, true
+		}
+	}
+
+	var diffs []Difference
+	for i := range new {
+		n := &new[i]
+		o := pair[n]
+		switch {
+		case o == nil:
+			diffs = append(diffs, Difference{Added, nil, n})
+		case o.Value != n.Value:
+			diffs = append(diffs, Difference{Revalued, o, n})
+		case !sameName(o, n):
+			diffs = append(diffs, Difference{Renamed, o, n})
+		case len(changedFields(o, n)) > 0:
+			diffs = append(diffs, Difference{Changed, o, n})
+		}
+	}
+	for i := range old {
+		if o := &old[i]; !matched[o] {
+			diffs = append(diffs, Difference{Removed, o, nil})
+		}
+	}
+	return diffs
+}
+
+func sameName(a, b *Unit) bool {
+	return a.Title == b.Title && a.MS == b.MS
+}
+
+// Summary counts the differences by kind, e.g. "2 added, 1 renamed".
+func Summary(diffs []Difference) string {
+	counts := map[string]int{}
+	for _, d := range diffs {
+		counts[d.Kind]++
+	}
+	var parts []string
+	for _, kind := range []string{Added, Removed, Renamed, Revalued, Changed} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// changedFields names the columns other than title, ms and value that
+// differ.
+func changedFields(o, n *Unit) []string {
+	var fields []string
+	add := func(name string, differs bool) {
+		if differs {
+			fields = append(fields, name)
+		}
+	}
+	add("id", o.ID != n.ID)
+	add("title_en", o.TitleEN != n.TitleEN)
+	add("ms_en", o.MSEN != n.MSEN)
+	add("romaji", o.Romaji != n.Romaji)
+	add("cost", o.Cost != n.Cost)
+	add("dlc", o.DLC != n.DLC)
+	add("version", o.Version != n.Version)
+	add("tags", !slices.Equal(o.Tags, n.Tags))
+	return fields
+}
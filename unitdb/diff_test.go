@@ -0,0 +1,65 @@
+package unitdb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffGolden(t *testing.T) {
+	old, err := Load("testdata/diff_old.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := Load("testdata/diff_new.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	diffs := Diff(old, new)
+	for _, d := range diffs {
+		fmt.Fprintln(&b, d)
+	}
+	fmt.Fprintln(&b, Summary(diffs))
+	golden(t, "diff.golden", []byte(b.String()))
+}
+
+func TestDiff(t *testing.T) {
+	gundam := Unit{ID: 1, Title: "機動戦士ガンダム", MS: "ガンダム", Value: 1001001}
+	gelgoog := Unit{ID: 2, Title: "機動戦士ガンダム", MS: "シャア専用ゲルググ", Value: 1002001}
+	with := func(u Unit, change func(*Unit)) Unit {
+		change(&u)
+		return u
+	}
+	tests := []struct {
+		name     string
+		old, new []Unit
+		want     []string // kinds
+	}{
+		{"same", []Unit{gundam, gelgoog}, []Unit{gundam, gelgoog}, nil},
+		{"swapped values", []Unit{gundam, gelgoog},
+			[]Unit{with(gundam, func(u *Unit) { u.Value = 1002001 }), with(gelgoog, func(u *Unit) { u.Value = 1001001 })},
+			[]string{Revalued, Revalued}},
+		{"renamed", []Unit{gundam}, []Unit{with(gundam, func(u *Unit) { u.MS = "ガンダム(Gメカ)" })}, []string{Renamed}},
+		{"new id, same value", []Unit{gundam}, []Unit{with(gundam, func(u *Unit) { u.ID = 10 })}, []string{Changed}},
+		{"new id and name", []Unit{gundam}, []Unit{with(gundam, func(u *Unit) { u.ID, u.MS = 10, "RX-78-2" })}, []string{Renamed}},
+		{"id reused for another unit", []Unit{gundam},
+			[]Unit{with(gundam, func(u *Unit) { u.MS, u.Value = "ジム", 1009001 })}, []string{Added, Removed}},
+		{"metadata", []Unit{gundam}, []Unit{with(gundam, func(u *Unit) { u.Cost, u.Tags = 2500, []string{"shooting"} })}, []string{Changed}},
+		{"added and removed", []Unit{gundam}, []Unit{gelgoog}, []string{Added, Removed}},
+	}
+	for _, tt := range tests {
+		var kinds []string
+		for _, d := range Diff(tt.old, tt.new) {
+			kinds = append(kinds, d.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.want) {
+			t.Errorf("%s: Diff kinds = %v, want %v", tt.name, kinds, tt.want)
+		}
+	}
+
+	if got := Summary(nil); got != "no changes" {
+		t.Errorf("Summary(nil) = %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package unitdb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormats and SortKeys are what Export and Sort accept.
+var (
+	ExportFormats = []string{"csv", "json", "yaml", "md"}
+	SortKeys      = []string{"title", "id", "value"}
+)
+
+// Sort orders units by title (then id), id or value.
+func Sort(units []Unit, by string) error {
+	var less func(a, b Unit) bool
+	switch by {
+	case "title":
+		less = func(a, b Unit) bool {
+			if a.Title != b.Title {
+				return a.Title < b.Title
+			}
+			return a.ID < b.ID
+		}
+	case "id":
+		less = func(a, b Unit) bool { return a.ID < b.ID }
+	case "value":
+		less = func(a, b Unit) bool { return a.Value < b.Value }
+	default:
+		return fmt.Errorf("unknown sort key %q (want %s)", by, strings.Join(SortKeys, ", "))
+	}
+	sort.SliceStable(units, func(i, j int) bool { return less(units[i], units[j]) })
+	return nil
+}
+
+// Export writes units as CSV (readable by Load, with the optional columns
+// that are used), JSON, YAML or a Markdown table.
+func Export(w io.Writer, units []Unit, format string) error {
+	switch format {
+	case "csv":
+		return exportCSV(w, units)
+	case "json", "yaml":
+		entries := make([]entry, 0, len(units))
+		for _, u := range units {
+			entries = append(entries, entry{
+				ID: u.ID, Title: u.Title, MS: u.MS, Value: u.Value,
+				TitleEN: u.TitleEN, MSEN: u.MSEN, Romaji: u.Romaji,
+				Cost: u.Cost, DLC: u.DLC, Version: u.Version, Tags: u.Tags,
+			})
+		}
+		if format == "yaml" {
+			enc := yaml.NewEncoder(w)
+			enc.SetIndent(2)
+			if err := enc.Encode(entries); err != nil {
+				return err
+			}
+			return enc.Close()
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(entries)
+	case "md":
+		fmt.Fprintln(w, "| id | title | ms | value | cost | tags |")
+		fmt.Fprintln(w, "|---:|---|---|---:|---:|---|")
+		for _, u := range units {
+			cost := ""
+			if u.Cost != 0 {
+				cost = strconv.Itoa(u.Cost)
+			}
+			fmt.Fprintf(w, "| %d | %s | %s | %d | %s | %s |\n", u.ID, mdEscape(u.Title), mdEscape(u.MS), u.Value, cost, mdEscape(strings.Join(u.Tags, ", ")))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(ExportFormats, ", "))
+}
+
+func exportCSV(w io.Writer, units []Unit) error {
+	values := func(u Unit) map[string]string {
+		v := map[string]string{
+			"id":       strconv.Itoa(int(u.ID)),
+			"title":    u.Title,
+			"ms":       u.MS,
+			"value":    strconv.Itoa(int(u.Value)),
+			"title_en": u.TitleEN,
+			"ms_en":    u.MSEN,
+			"romaji":   u.Romaji,
+			"version":  u.Version,
+			"tags":     strings.Join(u.Tags, "|"),
+		}
+		if u.Cost != 0 {
+			v["cost"] = strconv.Itoa(u.Cost)
+		}
+		if u.DLC {
+			v["dlc"] = "yes"
+		}
+		return v
+	}
+
+	// the four required columns, then the optional ones some unit uses
+	header := slices.Clone(columns[:4])
+	for _, c := range columns[4:] {
+		for _, u := range units {
+			if values(u)[c] != "" {
+				header = append(header, c)
+				break
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, u := range units {
+		v := values(u)
+		record := make([]string, len(header))
+		for i, c := range header {
+			record[i] = v[c]
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
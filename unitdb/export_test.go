@@ -0,0 +1,110 @@
+package unitdb
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// golden compares got with testdata/name, or rewrites it with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the output; run go test -update and check the diff\ngot:\n%s", path, head(got))
+	}
+}
+
+func head(b []byte) string {
+	lines := strings.SplitN(string(b), "\n", 11)
+	return strings.Join(lines[:min(len(lines), 10)], "\n")
+}
+
+func TestExportGolden(t *testing.T) {
+	for _, file := range []string{"../units.csv", "testdata/rich.csv"} {
+		units, err := Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := strings.TrimSuffix(filepath.Base(file), ".csv")
+		for _, format := range ExportFormats {
+			var buf bytes.Buffer
+			if err := Export(&buf, units, format); err != nil {
+				t.Fatalf("%s as %s: %v", file, format, err)
+			}
+			golden(t, fmt.Sprintf("%s.export.%s", base, format), buf.Bytes())
+
+			// Everything but the Markdown table loads back unchanged.
+			if format == "md" {
+				continue
+			}
+			back, err := Parse(buf.Bytes(), format, "export."+format)
+			if err != nil {
+				t.Fatalf("%s as %s does not load back: %v", file, format, err)
+			}
+			if !reflect.DeepEqual(noEmptyTags(back), noEmptyTags(units)) {
+				t.Errorf("%s as %s loads back differently", file, format)
+			}
+		}
+	}
+}
+
+// noEmptyTags sets empty tag lists to nil: CSV gives every unit a list,
+// JSON and YAML leave it out when a unit has no tags.
+func noEmptyTags(units []Unit) []Unit {
+	for i := range units {
+		if len(units[i].Tags) == 0 {
+			units[i].Tags = nil
+		}
+	}
+	return units
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	if err := Export(&bytes.Buffer{}, nil, "xml"); err == nil {
+		t.Error("Export accepted xml")
+	}
+}
+
+func TestSort(t *testing.T) {
+	units, err := Load("testdata/rich.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for by, want := range map[string][]int32{
+		"id":    {1, 2, 3},
+		"value": {1, 2, 3},
+		"title": {3, 2, 1}, // ビ < 機動戦士Z < 機動戦士ガ
+	} {
+		if err := Sort(units, by); err != nil {
+			t.Fatal(err)
+		}
+		var got []int32
+		for _, u := range units {
+			got = append(got, u.ID)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Sort by %s = %v, want %v", by, got, want)
+		}
+	}
+	if err := Sort(units, "cost"); err == nil {
+		t.Error("Sort accepted cost")
+	}
+}
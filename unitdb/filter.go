@@ -0,0 +1,91 @@
+package unitdb
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Filter selects units by metadata. Zero fields match everything.
+type Filter struct {
+	Costs   []int
+	DLC     string // "", "yes" or "no"
+	Version string
+	Tags    []string // all must be present
+}
+
+// RegisterFlags adds -cost, -dlc, -version and -tag to fs. They fill in f
+// as fs.Parse reads them, so f is ready to Apply once fs.Parse returns.
+func (f *Filter) RegisterFlags(fs *flag.FlagSet) {
+	fs.Func("cost", "only these costs, e.g. 2500,3000", func(s string) error {
+		for _, c := range strings.Split(s, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(c))
+			if err != nil {
+				return fmt.Errorf("invalid cost %q", c)
+			}
+			f.Costs = append(f.Costs, n)
+		}
+		return nil
+	})
+	fs.Func("dlc", "yes for DLC units only, no to leave them out", func(s string) error {
+		if s != "yes" && s != "no" {
+			return fmt.Errorf("-dlc must be yes or no")
+		}
+		f.DLC = s
+		return nil
+	})
+	fs.StringVar(&f.Version, "version", "", "only units added in this game version")
+	fs.Func("tag", "only units with these tags, e.g. melee,zoning", func(s string) error {
+		f.Tags = append(f.Tags, splitTags(strings.ReplaceAll(s, ",", "|"))...)
+		return nil
+	})
+}
+
+// Empty reports whether the filter matches every unit.
+func (f Filter) Empty() bool {
+	return len(f.Costs) == 0 && f.DLC == "" && f.Version == "" && len(f.Tags) == 0
+}
+
+// Match reports whether u passes the filter.
+func (f Filter) Match(u Unit) bool {
+	if len(f.Costs) > 0 {
+		ok := false
+		for _, c := range f.Costs {
+			ok = ok || u.Cost == c
+		}
+		if !ok {
+			return false
+		}
+	}
+	if (f.DLC == "yes" && !u.DLC) || (f.DLC == "no" && u.DLC) {
+		return false
+	}
+	if f.Version != "" && strings.TrimPrefix(u.Version, "v") != strings.TrimPrefix(f.Version, "v") {
+		return false
+	}
+	for _, want := range f.Tags {
+		found := false
+		for _, t := range u.Tags {
+			found = found || t == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the units that pass the filter.
+func (f Filter) Apply(units []Unit) []Unit {
+	if f.Empty() {
+		return units
+	}
+	var out []Unit
+	for _, u := range units {
+		if f.Match(u) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package unitdb
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestFilterFlags(t *testing.T) {
+	units, err := Load("testdata/rich.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		want []int32 // ids
+	}{
+		{nil, []int32{2, 1, 3}},
+		{[]string{"-cost", "2500,3000"}, []int32{2, 1}},
+		{[]string{"-cost", "2000", "-cost", "2500"}, []int32{1, 3}},
+		{[]string{"-dlc", "yes"}, []int32{3}},
+		{[]string{"-dlc", "no"}, []int32{2, 1}},
+		{[]string{"-version", "1.05"}, []int32{3}},
+		{[]string{"-version", "v1.00"}, []int32{2}},
+		{[]string{"-tag", "shooting,transform"}, []int32{2}},
+		{[]string{"-tag", "shooting", "-tag", "melee"}, nil},
+		{[]string{"-cost", "3000", "-dlc", "yes"}, nil},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("list", flag.ContinueOnError)
+		var f Filter
+		f.RegisterFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		var got []int32
+		for _, u := range f.Apply(units) {
+			got = append(got, u.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: Apply = %v, want %v", tt.args, got, tt.want)
+		}
+		if f.Empty() != (len(tt.args) == 0) {
+			t.Errorf("%v: Empty = %v", tt.args, f.Empty())
+		}
+	}
+
+	for _, bad := range [][]string{{"-cost", "high"}, {"-dlc", "maybe"}} {
+		fs := flag.NewFlagSet("list", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		var f Filter
+		f.RegisterFlags(fs)
+		if err := fs.Parse(bad); err == nil {
+			t.Errorf("%v: Parse accepted it", bad)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package unitdb
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Source tells where a database came from: a file, or the copy embedded in
+// the executable, plus the override files merged on top.
+type Source struct {
+	Path      string // file that was read; the configured name when Embedded
+	Embedded  bool
+	Overrides []string // override files applied, in order
+	Conflicts []string // reported by Merge
+}
+
+func (s Source) String() string {
+	name := s.Path
+	if s.Embedded {
+		name = "embedded " + filepath.Base(s.Path)
+	}
+	for _, o := range s.Overrides {
+		name += " + " + o
+	}
+	return name
+}
+
+// SearchPaths lists where a database file named name is looked for, in
+// order: as given (relative to the working directory), next to the
+// executable, and in the user config dir. An absolute name is only looked
+// for as given.
+func SearchPaths(name string) []string {
+	paths := []string{name}
+	if filepath.IsAbs(name) {
+		return paths
+	}
+	if exe, err := os.Executable(); err == nil {
+		if exe, err := filepath.EvalSymlinks(exe); err == nil {
+			paths = append(paths, filepath.Join(filepath.Dir(exe), name))
+		}
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "ms-changer", name))
+	}
+	return paths
+}
+
+// Find returns the first of SearchPaths(name) that exists, or "" when there
+// is none.
+func Find(name string) string {
+	for _, p := range SearchPaths(name) {
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// Open finds and loads the units file name. When no file exists it falls
+// back to the embedded copy (a units.csv), if one is given. The override
+// files, looked for the same way, are merged on top; missing ones are
+// skipped. Callers report the returned source to the user.
+func Open(name string, embedded []byte, overrides ...string) ([]Unit, Source, error) {
+	src := Source{Path: name}
+	var units []Unit
+	var err error
+	if path := Find(name); path != "" {
+		src.Path = path
+		units, err = Load(path)
+	} else if embedded != nil {
+		src.Embedded = true
+		units, err = Parse(embedded, "csv", src.String())
+	} else {
+		return nil, src, fmt.Errorf("%s not found in %v", name, SearchPaths(name))
+	}
+	if err != nil {
+		return nil, src, err
+	}
+
+	var layers []Layer
+	for _, o := range overrides {
+		path := Find(o)
+		if path == "" {
+			slog.Warn("⚠️ Override file not found", "file", o)
+			continue
+		}
+		layer, err := LoadOverride(path)
+		if err != nil {
+			return nil, src, err
+		}
+		layers = append(layers, layer)
+		src.Overrides = append(src.Overrides, path)
+	}
+	units, src.Conflicts = Merge(units, layers...)
+	for _, c := range src.Conflicts {
+		slog.Warn("⚠️ Override conflict", "conflict", c)
+	}
+	slog.Debug("units source", "source", src, "count", len(units))
+	return units, src, nil
+}
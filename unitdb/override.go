@@ -0,0 +1,135 @@
+package unitdb
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Change is one row of an override file. Rows are matched to the database
+// by value: an unknown value adds a unit, hide removes one, and otherwise
+// the non-empty fields replace the unit's (rename, re-title, ...).
+type Change struct {
+	Unit
+	Hide bool
+}
+
+// Layer is a loaded override file.
+type Layer struct {
+	Name    string
+	Changes []Change
+}
+
+// LoadOverride reads an override file. It has the same formats and columns
+// as a units file plus an optional hide column; only value is required.
+func LoadOverride(filename string) (Layer, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Layer{}, err
+	}
+	changes, err := parse(data, FormatOf(filename), filename, true)
+	if err != nil {
+		return Layer{}, err
+	}
+	return Layer{Name: filename, Changes: changes}, nil
+}
+
+// Merge applies the layers on top of base in order, so later layers win.
+// A hidden unit stays hidden even if a later layer edits it. It reports
+// conflicts: two layers setting the same field of a unit to different text,
+// editing a hidden unit, hiding an unknown value, or adding a unit without a
+// name or with a taken id (which is then renumbered).
+func Merge(base []Unit, layers ...Layer) ([]Unit, []string) {
+	units := slices.Clone(base)
+	byValue := map[int32]int{}
+	ids := map[int32]bool{}
+	var maxID int32
+	for i, u := range units {
+		byValue[u.Value] = i
+		ids[u.ID] = true
+		maxID = max(maxID, u.ID)
+	}
+	hidden := map[int32]string{} // value -> layer that hid it
+	type setBy struct{ layer, text string }
+	set := map[string]setBy{} // "value/field" -> layer that changed it
+
+	var conflicts []string
+	for _, layer := range layers {
+		for _, c := range layer.Changes {
+			i, known := byValue[c.Value]
+			switch {
+			case c.Hide && !known:
+				conflicts = append(conflicts, fmt.Sprintf("%s: cannot hide unknown value %d", layer.Name, c.Value))
+			case c.Hide:
+				hidden[c.Value] = layer.Name
+			case !known:
+				if c.MS == "" {
+					conflicts = append(conflicts, fmt.Sprintf("%s: new value %d has no ms name", layer.Name, c.Value))
+					continue
+				}
+				u := c.Unit
+				if u.ID == 0 || ids[u.ID] {
+					if u.ID != 0 {
+						conflicts = append(conflicts, fmt.Sprintf("%s: id %d of %s is taken, renumbered to %d", layer.Name, u.ID, u.MS, maxID+1))
+					}
+					u.ID = maxID + 1
+				}
+				maxID = max(maxID, u.ID)
+				ids[u.ID] = true
+				byValue[u.Value] = len(units)
+				units = append(units, u)
+			default:
+				if by, ok := hidden[c.Value]; ok {
+					conflicts = append(conflicts, fmt.Sprintf("%s hides %d, %s changes it; it stays hidden", by, c.Value, layer.Name))
+				}
+				for field, text := range apply(&units[i], c.Unit) {
+					key := fmt.Sprintf("%d/%s", c.Value, field)
+					if prev, ok := set[key]; ok && prev.layer != layer.Name && prev.text != text {
+						conflicts = append(conflicts, fmt.Sprintf("%s and %s both change %s of %d (%q, %q)", prev.layer, layer.Name, field, c.Value, prev.text, text))
+					}
+					set[key] = setBy{layer.Name, text}
+				}
+			}
+		}
+	}
+
+	merged := units[:0]
+	for _, u := range units {
+		if _, ok := hidden[u.Value]; !ok {
+			merged = append(merged, u)
+		}
+	}
+	return merged, conflicts
+}
+
+// apply copies the non-empty fields of c onto u and returns them by column
+// name.
+func apply(u *Unit, c Unit) map[string]string {
+	changed := map[string]string{}
+	text := func(name string, dst *string, src string) {
+		if src != "" {
+			*dst = src
+			changed[name] = src
+		}
+	}
+	text("title", &u.Title, c.Title)
+	text("ms", &u.MS, c.MS)
+	text("title_en", &u.TitleEN, c.TitleEN)
+	text("ms_en", &u.MSEN, c.MSEN)
+	text("romaji", &u.Romaji, c.Romaji)
+	text("version", &u.Version, c.Version)
+	if c.Cost != 0 {
+		u.Cost = c.Cost
+		changed["cost"] = fmt.Sprint(c.Cost)
+	}
+	if c.DLC {
+		u.DLC = true
+		changed["dlc"] = "yes"
+	}
+	if len(c.Tags) > 0 {
+		u.Tags = c.Tags
+		changed["tags"] = strings.Join(c.Tags, "|")
+	}
+	return changed
+}
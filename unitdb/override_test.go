@@ -0,0 +1,190 @@
+package unitdb
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base, err := Load("testdata/rich.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit := func(value int32, ms string) Change { return Change{Unit: Unit{Value: value, MS: ms}} }
+	hide := func(value int32) Change { return Change{Unit: Unit{Value: value}, Hide: true} }
+
+	tests := []struct {
+		name      string
+		layers    []Layer
+		want      map[int32]string // value -> ms of every merged unit
+		conflicts []string         // substrings, in order
+	}{
+		{
+			name: "no layers",
+			want: map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム", 9001001: "ビルド|ストライク"},
+		},
+		{
+			name: "later layer wins",
+			layers: []Layer{
+				{"team.csv", []Change{edit(1001001, "RX-78-2")}},
+				{"mine.csv", []Change{edit(1001001, "ガンダム (mine)")}},
+			},
+			want:      map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム (mine)", 9001001: "ビルド|ストライク"},
+			conflicts: []string{`team.csv and mine.csv both change ms of 1001001 ("RX-78-2", "ガンダム (mine)")`},
+		},
+		{
+			name: "same text in both layers",
+			layers: []Layer{
+				{"team.csv", []Change{edit(1001001, "RX-78-2")}},
+				{"mine.csv", []Change{edit(1001001, "RX-78-2")}},
+			},
+			want: map[int32]string{2001001: "Zガンダム", 1001001: "RX-78-2", 9001001: "ビルド|ストライク"},
+		},
+		{
+			name:   "hide",
+			layers: []Layer{{"team.csv", []Change{hide(9001001)}}},
+			want:   map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム"},
+		},
+		{
+			name:      "hide an unknown value",
+			layers:    []Layer{{"team.csv", []Change{hide(5001001)}}},
+			want:      map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム", 9001001: "ビルド|ストライク"},
+			conflicts: []string{"team.csv: cannot hide unknown value 5001001"},
+		},
+		{
+			name: "edit after hide keeps the unit hidden",
+			layers: []Layer{
+				{"team.csv", []Change{hide(9001001)}},
+				{"mine.csv", []Change{edit(9001001, "ストライク")}},
+			},
+			want:      map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム"},
+			conflicts: []string{"team.csv hides 9001001, mine.csv changes it; it stays hidden"},
+		},
+		{
+			name: "hide after edit",
+			layers: []Layer{
+				{"team.csv", []Change{edit(9001001, "ストライク")}},
+				{"mine.csv", []Change{hide(9001001)}},
+			},
+			want: map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム"},
+		},
+		{
+			name: "add",
+			layers: []Layer{{"team.csv", []Change{
+				{Unit: Unit{Value: 3001001, MS: "百式"}},
+				{Unit: Unit{ID: 10, Value: 3002001, MS: "キュベレイ"}},
+			}}},
+			want: map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム", 9001001: "ビルド|ストライク", 3001001: "百式", 3002001: "キュベレイ"},
+		},
+		{
+			name:      "add without a name",
+			layers:    []Layer{{"team.csv", []Change{{Unit: Unit{Value: 3001001}}}}},
+			want:      map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム", 9001001: "ビルド|ストライク"},
+			conflicts: []string{"team.csv: new value 3001001 has no ms name"},
+		},
+		{
+			name:      "add with a taken id",
+			layers:    []Layer{{"team.csv", []Change{{Unit: Unit{ID: 1, Value: 3001001, MS: "百式"}}}}},
+			want:      map[int32]string{2001001: "Zガンダム", 1001001: "ガンダム", 9001001: "ビルド|ストライク", 3001001: "百式"},
+			conflicts: []string{"team.csv: id 1 of 百式 is taken, renumbered to 4"},
+		},
+	}
+	for _, tt := range tests {
+		units, conflicts := Merge(base, tt.layers...)
+		got := map[int32]string{}
+		for _, u := range units {
+			got[u.Value] = u.MS
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Merge = %v, want %v", tt.name, got, tt.want)
+		}
+		if len(conflicts) != len(tt.conflicts) {
+			t.Errorf("%s: conflicts = %q, want %q", tt.name, conflicts, tt.conflicts)
+			continue
+		}
+		for i, c := range conflicts {
+			if !strings.Contains(c, tt.conflicts[i]) {
+				t.Errorf("%s: conflict %q, want %q", tt.name, c, tt.conflicts[i])
+			}
+		}
+	}
+
+	// The base slice is left alone.
+	if again, _ := Load("testdata/rich.csv"); !reflect.DeepEqual(base, again) {
+		t.Error("Merge modified the base units")
+	}
+}
+
+func TestMergeIDs(t *testing.T) {
+	base, err := Load("testdata/rich.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, _ := Merge(base, Layer{"team.csv", []Change{
+		{Unit: Unit{Value: 3001001, MS: "百式"}},
+		{Unit: Unit{ID: 10, Value: 3002001, MS: "キュベレイ"}},
+		{Unit: Unit{Value: 3003001, MS: "ジ・O"}},
+	}})
+	var ids []int32
+	for _, u := range units {
+		ids = append(ids, u.ID)
+	}
+	if want := []int32{2, 1, 3, 4, 10, 11}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+}
+
+func TestLoadOverride(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Only value is required; columns may come in any order.
+	path := write("team.csv", "value,ms,hide\n1001001,RX-78-2,\n9001001,,yes\nnot a value,百式,\n")
+	layer, err := LoadOverride(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Layer{Name: path, Changes: []Change{
+		{Unit: Unit{Value: 1001001, MS: "RX-78-2", Tags: []string{}}},
+		{Unit: Unit{Value: 9001001, Tags: []string{}}, Hide: true},
+	}}
+	if !reflect.DeepEqual(layer, want) {
+		t.Errorf("LoadOverride = %+v, want %+v (the row with a bad value skipped)", layer, want)
+	}
+
+	path = write("mine.json", `[{"value": 1001001, "cost": 3000, "tags": ["Melee"]}, {"value": 2001001, "hide": true}]`)
+	if layer, err = LoadOverride(path); err != nil {
+		t.Fatal(err)
+	}
+	want = Layer{Name: path, Changes: []Change{
+		{Unit: Unit{Value: 1001001, Cost: 3000, Tags: []string{"melee"}}},
+		{Unit: Unit{Value: 2001001}, Hide: true},
+	}}
+	if !reflect.DeepEqual(layer, want) {
+		t.Errorf("LoadOverride = %+v, want %+v", layer, want)
+	}
+
+	for name, data := range map[string]string{
+		"broken.json": `[{"value": 1001001,`,
+		"wrong.json":  `{"value": 1001001}`,
+		"quote.csv":   "value,ms\n1001001,\"RX-78-2\n",
+		"empty.csv":   "",
+		"tabs.yaml":   "- value: 1001001\n\tms: RX-78-2\n",
+	} {
+		if _, err := LoadOverride(write(name, data)); err == nil {
+			t.Errorf("LoadOverride accepted %s", name)
+		}
+	}
+	if _, err := LoadOverride(filepath.Join(dir, "missing.csv")); err == nil {
+		t.Error("LoadOverride of a missing file succeeded")
+	}
+}
@@ -0,0 +1,124 @@
+package unitdb
+
+import (
+	"strings"
+	"unicode"
+)
+
+// kana maps katakana to Hepburn romaji. Two-character entries (キャ, ファ)
+// are tried before single characters.
+var kana = map[string]string{
+	"ア": "a", "イ": "i", "ウ": "u", "エ": "e", "オ": "o",
+	"カ": "ka", "キ": "ki", "ク": "ku", "ケ": "ke", "コ": "ko",
+	"サ": "sa", "シ": "shi", "ス": "su", "セ": "se", "ソ": "so",
+	"タ": "ta", "チ": "chi", "ツ": "tsu", "テ": "te", "ト": "to",
+	"ナ": "na", "ニ": "ni", "ヌ": "nu", "ネ": "ne", "ノ": "no",
+	"ハ": "ha", "ヒ": "hi", "フ": "fu", "ヘ": "he", "ホ": "ho",
+	"マ": "ma", "ミ": "mi", "ム": "mu", "メ": "me", "モ": "mo",
+	"ヤ": "ya", "ユ": "yu", "ヨ": "yo",
+	"ラ": "ra", "リ": "ri", "ル": "ru", "レ": "re", "ロ": "ro",
+	"ワ": "wa", "ヰ": "i", "ヱ": "e", "ヲ": "o", "ン": "n",
+	"ガ": "ga", "ギ": "gi", "グ": "gu", "ゲ": "ge", "ゴ": "go",
+	"ザ": "za", "ジ": "ji", "ズ": "zu", "ゼ": "ze", "ゾ": "zo",
+	"ダ": "da", "ヂ": "ji", "ヅ": "zu", "デ": "de", "ド": "do",
+	"バ": "ba", "ビ": "bi", "ブ": "bu", "ベ": "be", "ボ": "bo",
+	"パ": "pa", "ピ": "pi", "プ": "pu", "ペ": "pe", "ポ": "po",
+	"ヴ": "vu",
+	"ァ": "a", "ィ": "i", "ゥ": "u", "ェ": "e", "ォ": "o",
+	"ャ": "ya", "ュ": "yu", "ョ": "yo", "ヮ": "wa",
+
+	"キャ": "kya", "キュ": "kyu", "キョ": "kyo",
+	"シャ": "sha", "シュ": "shu", "ショ": "sho", "シェ": "she",
+	"チャ": "cha", "チュ": "chu", "チョ": "cho", "チェ": "che",
+	"ニャ": "nya", "ニュ": "nyu", "ニョ": "nyo",
+	"ヒャ": "hya", "ヒュ": "hyu", "ヒョ": "hyo",
+	"ミャ": "mya", "ミュ": "myu", "ミョ": "myo",
+	"リャ": "rya", "リュ": "ryu", "リョ": "ryo",
+	"ギャ": "gya", "ギュ": "gyu", "ギョ": "gyo",
+	"ジャ": "ja", "ジュ": "ju", "ジョ": "jo", "ジェ": "je",
+	"ビャ": "bya", "ビュ": "byu", "ビョ": "byo",
+	"ピャ": "pya", "ピュ": "pyu", "ピョ": "pyo",
+	"ファ": "fa", "フィ": "fi", "フェ": "fe", "フォ": "fo",
+	"ヴァ": "va", "ヴィ": "vi", "ヴェ": "ve", "ヴォ": "vo",
+	"ウィ": "wi", "ウェ": "we", "ウォ": "wo",
+	"ティ": "ti", "ディ": "di", "トゥ": "tu", "ドゥ": "du",
+	"デュ": "dyu", "テュ": "tyu", "クァ": "kwa", "グァ": "gwa",
+	"ツァ": "tsa", "ツィ": "tsi", "ツェ": "tse", "ツォ": "tso",
+}
+
+// symbols spells out letters that appear in unit names.
+var symbols = map[rune]string{
+	'ν': "nu", 'Ξ': "xi", 'ξ': "xi", '∀': "turn a", 'Ⅱ': "ii", 'Ⅲ': "iii",
+}
+
+// Romanize transliterates hiragana and katakana in s to romaji and folds
+// full-width letters to ASCII. Long vowel marks are dropped, so ゼータ
+// becomes "zeta" as people type it. Other characters are kept.
+func Romanize(s string) string {
+	runes := []rune(toKatakana(foldWidth(s)))
+	var b strings.Builder
+	double := false // after a small ッ
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == 'ッ' {
+			double = true
+			continue
+		}
+		if r == 'ー' {
+			continue
+		}
+
+		roma := ""
+		if i+1 < len(runes) {
+			if v, ok := kana[string(runes[i:i+2])]; ok {
+				roma = v
+				i++
+			}
+		}
+		if roma == "" {
+			if v, ok := kana[string(r)]; ok {
+				roma = v
+			} else if v, ok := symbols[r]; ok {
+				roma = v
+			}
+		}
+		if roma == "" {
+			b.WriteRune(unicode.ToLower(r))
+			double = false
+			continue
+		}
+		if double {
+			if roma[0] == 'c' {
+				b.WriteByte('t') // ッチ -> tchi
+			} else {
+				b.WriteByte(roma[0])
+			}
+			double = false
+		}
+		b.WriteString(roma)
+	}
+	return b.String()
+}
+
+// toKatakana converts hiragana to katakana.
+func toKatakana(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			return r + ('ァ' - 'ぁ')
+		}
+		return r
+	}, s)
+}
+
+// foldWidth turns full-width ASCII (Ａ, ０, ！) into its normal form.
+func foldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '！' && r <= '～' {
+			return r - 0xFEE0
+		}
+		if r == '　' {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,344 @@
+package unitdb
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultAliasesFile is read next to the units file when it exists.
+const DefaultAliasesFile = "aliases.csv"
+
+// AliasesPath returns the aliases file that belongs to a units file.
+func AliasesPath(unitsFile string) string {
+	return filepath.Join(filepath.Dir(unitsFile), DefaultAliasesFile)
+}
+
+// Aliases are groups of interchangeable terms, e.g. {"Z", "ゼータ", "zeta"}.
+type Aliases [][]string
+
+// LoadAliases reads an aliases file: one group of equivalent terms per
+// line, separated by commas. Lines starting with # are comments. A missing
+// file means no aliases.
+func LoadAliases(filename string) (Aliases, error) {
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseAliases(f)
+}
+
+// OpenAliases loads the aliases file that belongs to a units file, looked
+// for like the units file itself, falling back to the embedded copy.
+func OpenAliases(unitsFile string, embedded []byte) (Aliases, error) {
+	if path := Find(AliasesPath(unitsFile)); path != "" {
+		return LoadAliases(path)
+	}
+	return parseAliases(bytes.NewReader(embedded))
+}
+
+func parseAliases(rd io.Reader) (Aliases, error) {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var aliases Aliases
+	for _, record := range records {
+		var group []string
+		for _, term := range record {
+			if term = strings.TrimSpace(term); term != "" {
+				group = append(group, term)
+			}
+		}
+		if len(group) > 1 {
+			aliases = append(aliases, group)
+		}
+	}
+	return aliases, nil
+}
+
+// Index searches units by name, title, English name, romaji and aliases.
+type Index struct {
+	units   []Unit
+	aliases Aliases
+	names   [][]string       // normalized search keys for the unit name
+	other   [][]string       // keys for the title and tags, ranked below name matches
+	terms   []map[string]int // alias terms found in the name, with their score
+}
+
+// Match is a search result; a higher Score is a better match.
+type Match struct {
+	Unit  Unit
+	Score int
+}
+
+// NewIndex builds the search keys for every unit.
+func NewIndex(units []Unit, aliases Aliases) *Index {
+	ix := &Index{units: units, aliases: aliases}
+	for _, u := range units {
+		names := keys(aliases, u.MS, u.MSEN, u.Romaji)
+		other := keys(aliases, append([]string{u.Title, u.TitleEN}, u.Tags...)...)
+		ix.names = append(ix.names, names)
+		ix.other = append(ix.other, other)
+		ix.terms = append(ix.terms, aliasTerms(aliases, u.MS, u.MSEN, u.Romaji))
+	}
+	return ix
+}
+
+// aliasTerms returns the terms of every alias group that occurs in one of
+// texts, normalized and romanized, with a score. A term the text starts
+// with scores above any prefix match, since a query that is exactly "Z" or
+// "ゼータ" names Zガンダム more surely than it starts "zuda"; one further in
+// scores above a substring match. Shorter texts score higher.
+func aliasTerms(aliases Aliases, texts ...string) map[string]int {
+	terms := map[string]int{}
+	for _, t := range texts {
+		for _, group := range aliases {
+			for _, term := range group {
+				if t == "" || !containsTerm(t, term) {
+					continue
+				}
+				sc := 95 - min(len([]rune(normalize(t)))-len([]rune(normalize(term))), 5)
+				if !strings.HasPrefix(t, term) {
+					sc -= 15 // in the middle, like a substring match
+				}
+				for _, other := range group {
+					for _, k := range []string{normalize(other), normalize(Romanize(other))} {
+						if k != "" {
+							terms[k] = max(terms[k], sc)
+						}
+					}
+				}
+			}
+		}
+	}
+	return terms
+}
+
+// keys returns the normalized and romanized forms of each text and of its
+// alias variants.
+func keys(aliases Aliases, texts ...string) []string {
+	seen := map[string]bool{}
+	var out []string
+	add := func(s string) {
+		for _, k := range []string{normalize(s), normalize(Romanize(s))} {
+			if k != "" && !seen[k] {
+				seen[k] = true
+				out = append(out, k)
+			}
+		}
+	}
+	for _, t := range texts {
+		if t == "" {
+			continue
+		}
+		add(t)
+		for _, group := range aliases {
+			for _, term := range group {
+				if !containsTerm(t, term) {
+					continue
+				}
+				for _, other := range group {
+					if other != term {
+						add(strings.Replace(t, term, other, 1))
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// containsTerm reports whether term occurs in s without being part of a
+// longer run of letters or digits of the same kind, so the alias "Z" applies
+// to "Zガンダム" but not to "ZZガンダム".
+func containsTerm(s, term string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], term)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(term)
+		before, after := lastRune(s[:start]), firstRune(s[end:])
+		if !sameKind(before, firstRune(term)) && !sameKind(after, lastRune(term)) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	if len(r) == 0 {
+		return 0
+	}
+	return r[len(r)-1]
+}
+
+// sameKind reports whether a and b are both ASCII letters or digits.
+func sameKind(a, b rune) bool {
+	ascii := func(r rune) bool { return r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r)) }
+	return ascii(a) && ascii(b)
+}
+
+// normalize lowercases s, folds width and kana and drops spaces and
+// punctuation so "Zガンダム", "ｚがんだむ" and "Z-GUNDAM" compare well.
+func normalize(s string) string {
+	s = strings.ToLower(toKatakana(foldWidth(s)))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || r == 'ー' || r == '・' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Search returns the units matching query, best first, at most limit of
+// them (0 = all). Exact and prefix matches rank above substrings, which
+// rank above typo-tolerant and subsequence matches; a query that is a whole
+// alias term ranks just below an exact match; a match on the name
+// ranks above the same match on the title.
+func (ix *Index) Search(query string, limit int) []Match {
+	qs := queries(query)
+	if qs == nil {
+		return nil
+	}
+
+	var matches []Match
+	for i, u := range ix.units {
+		best := 0
+		for _, q := range qs {
+			best = max(best, bestScore(q, ix.names[i]), ix.terms[i][q])
+			best = max(best, bestScore(q, ix.other[i])-15)
+		}
+		if best > 0 {
+			matches = append(matches, Match{Unit: u, Score: best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Score rates how well query matches any of texts, the way Search rates a
+// unit name, so other things (series, commands) can be ranked alongside
+// units. 0 means no match.
+func (ix *Index) Score(query string, texts ...string) int {
+	var aliases Aliases
+	if ix != nil {
+		aliases = ix.aliases
+	}
+	ks, terms := keys(aliases, texts...), aliasTerms(aliases, texts...)
+	best := 0
+	for _, q := range queries(query) {
+		best = max(best, bestScore(q, ks), terms[q])
+	}
+	return best
+}
+
+// queries returns the normalized and romanized forms of a query, or nil
+// for an empty one.
+func queries(query string) []string {
+	qs := []string{normalize(query)}
+	if qs[0] == "" {
+		return nil
+	}
+	if r := normalize(Romanize(query)); r != qs[0] {
+		qs = append(qs, r)
+	}
+	return qs
+}
+
+func bestScore(q string, keys []string) int {
+	best := 0
+	for _, k := range keys {
+		best = max(best, score(q, k))
+	}
+	return best
+}
+
+// score rates how well query q matches key k, 0 meaning no match.
+func score(q, k string) int {
+	switch {
+	case q == k:
+		return 100
+	case strings.HasPrefix(k, q):
+		return 90 - min(len([]rune(k))-len([]rune(q)), 10)
+	case strings.Contains(k, q):
+		return 70 - min(len([]rune(k))-len([]rune(q)), 10)
+	}
+
+	// Typos: compare against the start of the key, allowing one edit per
+	// four characters of the query.
+	qr, kr := []rune(q), []rune(k)
+	if len(qr) >= 3 {
+		allowed := max(1, len(qr)/4)
+		prefix := kr[:min(len(kr), len(qr)+allowed)]
+		best := allowed + 1
+		for n := max(0, len(qr)-allowed); n <= len(prefix); n++ {
+			best = min(best, distance(qr, prefix[:n]))
+		}
+		if best <= allowed {
+			return 50 - 10*best
+		}
+	}
+	if len(qr) >= 3 && subsequence(qr, kr) {
+		return 20
+	}
+	return 0
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// subsequence reports whether every rune of q appears in k in order.
+func subsequence(q, k []rune) bool {
+	i := 0
+	for _, r := range k {
+		if i < len(q) && q[i] == r {
+			i++
+		}
+	}
+	return i == len(q)
+}
@@ -0,0 +1,157 @@
+package unitdb
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func testIndex(t *testing.T) *Index {
+	t.Helper()
+	units := []Unit{
+		{ID: 1, Title: "機動戦士ガンダム", MS: "ガンダム", Value: 1001001},
+		{ID: 2, Title: "機動戦士ガンダム", MS: "シャア専用ゲルググ", Value: 1002001},
+		{ID: 3, Title: "機動戦士ガンダム", MS: "ザクII", Value: 1003001},
+		{ID: 4, Title: "機動戦士Zガンダム", MS: "Zガンダム", Value: 2001001},
+		{ID: 5, Title: "機動戦士ガンダムZZ", MS: "ZZガンダム", Value: 3001001},
+		{ID: 6, Title: "機動戦士ガンダムSEED DESTINY", MS: "ストライクフリーダムガンダム", Value: 4001001},
+		{ID: 7, Title: "機動戦士ガンダム MS IGLOO", MS: "ヅダ", Value: 5001001},
+		{ID: 8, Title: "機動戦士Vガンダム", MS: "ゾロ", Value: 6001001},
+		{ID: 9, Title: "機動戦士Zガンダム", MS: "百式", Value: 2002001, MSEN: "Hyaku Shiki", Tags: []string{"shooting"}},
+	}
+	aliases, err := parseAliases(strings.NewReader("Z,ゼータ,zeta\nガンダム,gundam\nストライクフリーダム,ストフリ\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewIndex(units, aliases)
+}
+
+func TestSearch(t *testing.T) {
+	ix := testIndex(t)
+	tests := []struct {
+		name  string
+		query string
+		want  []int32 // IDs of the first results, best first; nil for none
+	}{
+		{"exact kana", "ガンダム", []int32{1}},
+		{"romaji", "gandamu", []int32{1}},
+		{"hiragana", "がんだむ", []int32{1}},
+		{"full width", "ＺＧＡＮＤＡＭＵ", []int32{4}},
+		{"alias to kana", "gundam", []int32{1}},
+		{"alias in name", "zeta", []int32{4}},
+		{"alias in kana", "ゼータガンダム", []int32{4}},
+		{"nickname", "ストフリ", []int32{6}},
+		{"prefix", "シャア", []int32{2}},
+		{"substring", "ゲルググ", []int32{2}},
+		{"english name", "hyaku", []int32{9}},
+		{"typo", "gandamo", []int32{1}},
+		{"subsequence", "sutfuri", []int32{6}},
+		{"exact alias beats romaji prefix", "Z", []int32{4}},
+		{"ZZ is not Z", "ZZ", []int32{5}},
+		{"name beats title", "ザク", []int32{3}},
+		{"no match", "ジオング", nil},
+		{"empty", " ", nil},
+	}
+	for _, tt := range tests {
+		matches := ix.Search(tt.query, 0)
+		var got []int32
+		for _, m := range matches[:min(len(matches), len(tt.want))] {
+			got = append(got, m.Unit.ID)
+		}
+		if tt.want == nil && len(matches) != 0 || !equalIDs(got, tt.want) {
+			t.Errorf("%s: Search(%q) starts with %v, want %v (all: %v)", tt.name, tt.query, got, tt.want, matches)
+		}
+	}
+}
+
+func equalIDs(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchRanking(t *testing.T) {
+	ix := testIndex(t)
+	tests := []struct {
+		query        string
+		better, than int32 // unit IDs
+	}{
+		{"Z", 4, 7},           // exact alias hit above the romaji prefix of ヅダ (zuda)
+		{"Z", 4, 8},           // and ゾロ (zoro)
+		{"Z", 4, 5},           // ZZガンダム does not contain the term Z
+		{"ガンダム", 1, 4},        // exact above the term further in
+		{"ガンダム", 4, 2},        // a name match above none
+		{"zeta", 4, 9},        // name above title
+		{"gundam", 6, 3},      // term in the name above no match
+		{"ストフリ", 6, 1},        // nickname above everything else
+		{"hyaku shiki", 9, 4}, // English name
+	}
+	for _, tt := range tests {
+		scores := map[int32]int{}
+		for _, m := range ix.Search(tt.query, 0) {
+			scores[m.Unit.ID] = m.Score
+		}
+		if scores[tt.better] <= scores[tt.than] {
+			t.Errorf("Search(%q): unit %d scores %d, not above unit %d at %d",
+				tt.query, tt.better, scores[tt.better], tt.than, scores[tt.than])
+		}
+	}
+
+	// A limit keeps the best matches.
+	if got := ix.Search("ガンダム", 2); len(got) != 2 || got[0].Unit.ID != 1 {
+		t.Errorf("Search limit 2 = %v", got)
+	}
+}
+
+func TestScore(t *testing.T) {
+	ix := testIndex(t)
+	if got := ix.Score("zeta", "機動戦士Zガンダム"); got == 0 {
+		t.Error("Score(zeta, 機動戦士Zガンダム) = 0, want a match through the alias")
+	}
+	if got := ix.Score("start", "Start writing"); got == 0 {
+		t.Error("Score(start, Start writing) = 0")
+	}
+	if got := ix.Score("xyz", "Stop writing"); got != 0 {
+		t.Errorf("Score(xyz, Stop writing) = %d, want 0", got)
+	}
+	var nilIndex *Index
+	if got := nilIndex.Score("stop", "Stop writing"); got == 0 {
+		t.Error("a nil Index does not score")
+	}
+}
+
+// TestSearchShipped checks the examples from the README against the shipped
+// units and aliases.
+func TestSearchShipped(t *testing.T) {
+	units, err := Load("../units.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("../aliases.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := parseAliases(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix := NewIndex(units, aliases)
+	for query, want := range map[string]string{
+		"gundam": "ガンダム",
+		"zeta":   "Zガンダム",
+		"Z":      "Zガンダム",
+		"ストフリ":   "ストライクフリーダムガンダム",
+		"zaku":   "ザク",
+	} {
+		matches := ix.Search(query, 1)
+		if len(matches) == 0 || !strings.HasPrefix(matches[0].Unit.MS, want) {
+			t.Errorf("Search(%q) = %v, want %s first", query, matches, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package unitdb
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultSeriesFile is read next to the units file when it exists.
+const DefaultSeriesFile = "series.csv"
+
+// SeriesPath returns the series file that belongs to a units file.
+func SeriesPath(unitsFile string) string {
+	return filepath.Join(filepath.Dir(unitsFile), DefaultSeriesFile)
+}
+
+// Series describes one title of the units database: how it is labelled,
+// which icon it gets and where it sorts.
+type Series struct {
+	Code   string // short id, e.g. "z"
+	Title  string // matches Unit.Title
+	Name   string
+	NameEN string
+	Short  string // tab label, e.g. "Z"
+	Year   int
+	Icon   string // emoji
+	Sort   int
+}
+
+// DisplayName returns the series name in the given language, falling back
+// to the title.
+func (s Series) DisplayName(lang string) string {
+	if lang == "en" && s.NameEN != "" {
+		return s.NameEN
+	}
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Title
+}
+
+// Label is the short label for tabs, falling back to DisplayName.
+func (s Series) Label(lang string) string {
+	if s.Short != "" {
+		return s.Short
+	}
+	return s.DisplayName(lang)
+}
+
+// Catalog looks up series by title. A nil Catalog knows no series.
+type Catalog struct {
+	byTitle map[string]Series
+}
+
+// LoadSeries reads a series file: CSV with the columns code, title, name,
+// name_en, short, year, icon and sort (only title is required); lines
+// starting with # are comments. A missing file means no series.
+func LoadSeries(filename string) (*Catalog, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseSeries(bytes.NewReader(data), filename)
+}
+
+// OpenSeries loads the series file that belongs to a units file, looked
+// for like the units file itself, falling back to the embedded copy.
+func OpenSeries(unitsFile string, embedded []byte) (*Catalog, error) {
+	if path := Find(SeriesPath(unitsFile)); path != "" {
+		return LoadSeries(path)
+	}
+	return parseSeries(bytes.NewReader(embedded), "embedded "+DefaultSeriesFile)
+}
+
+func parseSeries(rd io.Reader, name string) (*Catalog, error) {
+	r := csv.NewReader(rd)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{byTitle: map[string]Series{}}
+	if len(records) == 0 {
+		return c, nil
+	}
+	col := map[string]int{}
+	for i, h := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")))] = i
+	}
+	if _, ok := col["title"]; !ok {
+		return nil, fmt.Errorf("%s: no title column", name)
+	}
+	field := func(record []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	for _, record := range records[1:] {
+		s := Series{
+			Code:   field(record, "code"),
+			Title:  field(record, "title"),
+			Name:   field(record, "name"),
+			NameEN: field(record, "name_en"),
+			Short:  field(record, "short"),
+			Icon:   field(record, "icon"),
+		}
+		s.Year, _ = strconv.Atoi(field(record, "year"))
+		s.Sort, _ = strconv.Atoi(field(record, "sort"))
+		if s.Title != "" {
+			c.byTitle[s.Title] = s
+		}
+	}
+	return c, nil
+}
+
+// Lookup returns the series of a title, or false when it is not listed.
+func (c *Catalog) Lookup(title string) (Series, bool) {
+	if c == nil {
+		return Series{}, false
+	}
+	s, ok := c.byTitle[title]
+	return s, ok
+}
+
+// Missing lists the titles of units that have no series entry.
+func (c *Catalog) Missing(units []Unit) []string {
+	var missing []string
+	seen := map[string]bool{}
+	for _, u := range units {
+		if _, ok := c.Lookup(u.Title); !ok && !seen[u.Title] {
+			seen[u.Title] = true
+			missing = append(missing, u.Title)
+		}
+	}
+	return missing
+}
+
+// Group is the units of one series.
+type Group struct {
+	Series
+	Units []Unit
+}
+
+// Group splits units by title, keeping their order within each group.
+// Groups are ordered by sort key; titles without a series entry come last
+// in order of appearance, labelled with the unit's title and a 📺 icon.
+func (c *Catalog) Group(units []Unit) []Group {
+	index := map[string]int{}
+	var groups []Group
+	for _, u := range units {
+		i, ok := index[u.Title]
+		if !ok {
+			s, known := c.Lookup(u.Title)
+			if !known {
+				s = Series{Title: u.Title, Sort: math.MaxInt}
+			}
+			if s.NameEN == "" {
+				s.NameEN = u.TitleEN
+			}
+			if s.Icon == "" {
+				s.Icon = "📺"
+			}
+			i = len(groups)
+			index[u.Title] = i
+			groups = append(groups, Group{Series: s})
+		}
+		groups[i].Units = append(groups[i].Units, u)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Sort < groups[j].Sort })
+	return groups
+}
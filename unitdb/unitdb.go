@@ -0,0 +1,272 @@
+// Package unitdb loads the unit database (units.csv, or the same columns
+// as TSV, JSON or YAML) shared by the GUI and the CLIs.
+package unitdb
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Unit is one row of the database. Everything after Value comes from
+// optional columns and may be empty.
+type Unit struct {
+	ID      int32
+	Title   string
+	MS      string
+	Value   int32
+	TitleEN string
+	MSEN    string
+	Romaji  string
+	Cost    int      // 1500, 2000, 2500 or 3000; 0 when unknown
+	DLC     bool     // sold separately
+	Version string   // game version the unit arrived in
+	Tags    []string // e.g. "melee", "zoning"
+}
+
+// Meta describes cost, DLC, version and tags, e.g. "3000 · DLC · v1.05 · melee".
+func (u Unit) Meta() string {
+	var parts []string
+	if u.Cost != 0 {
+		parts = append(parts, strconv.Itoa(u.Cost))
+	}
+	if u.DLC {
+		parts = append(parts, "DLC")
+	}
+	if u.Version != "" {
+		parts = append(parts, "v"+strings.TrimPrefix(u.Version, "v"))
+	}
+	parts = append(parts, u.Tags...)
+	return strings.Join(parts, " · ")
+}
+
+// DisplayTitle returns the series title in the given language, falling back
+// to the original title.
+func (u Unit) DisplayTitle(lang string) string {
+	if lang == "en" && u.TitleEN != "" {
+		return u.TitleEN
+	}
+	return u.Title
+}
+
+// DisplayName returns the unit name in the given language, falling back to
+// the original name.
+func (u Unit) DisplayName(lang string) string {
+	if lang == "en" && u.MSEN != "" {
+		return u.MSEN
+	}
+	return u.MS
+}
+
+// columns lists the known columns; the first four are required and are
+// taken by position when the header does not name them.
+var columns = []string{"id", "title", "ms", "value", "title_en", "ms_en", "romaji", "cost", "dlc", "version", "tags", "hide"}
+
+// Load reads a units file in file order. The format follows the extension:
+// .csv, .tsv, .json, .yaml/.yml.
+func Load(filename string) ([]Unit, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	units, err := Parse(data, FormatOf(filename), filename)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("units loaded", "file", filename, "count", len(units))
+	return units, nil
+}
+
+// FormatOf returns the database format for a file name: "csv", "tsv",
+// "json" or "yaml". Unknown extensions are read as CSV.
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".tsv", ".tab":
+		return "tsv"
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "csv"
+}
+
+// Parse decodes a units database in the given format; name is only used in
+// messages.
+func Parse(data []byte, format, name string) ([]Unit, error) {
+	changes, err := parse(data, format, name, false)
+	if err != nil {
+		return nil, err
+	}
+	units := make([]Unit, 0, len(changes))
+	for _, c := range changes {
+		if !c.Hide {
+			units = append(units, c.Unit)
+		}
+	}
+	return units, nil
+}
+
+// parse decodes the rows of a database or, when override is set, of an
+// override file, where the id column may be left empty.
+func parse(data []byte, format, name string, override bool) ([]Change, error) {
+	switch format {
+	case "csv", "tsv":
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		if format == "tsv" {
+			r.Comma = '\t'
+			r.LazyQuotes = true
+		}
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("%s is empty", name)
+		}
+		return fromRecords(records, name, override), nil
+	case "json", "yaml":
+		var entries []entry
+		var err error
+		if format == "json" {
+			err = json.Unmarshal(data, &entries)
+		} else {
+			err = yaml.Unmarshal(data, &entries)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		changes := make([]Change, 0, len(entries))
+		for _, e := range entries {
+			changes = append(changes, e.change())
+		}
+		return changes, nil
+	}
+	return nil, fmt.Errorf("unknown units format %q", format)
+}
+
+// entry is one unit in a JSON or YAML database; the keys match the CSV
+// header names.
+type entry struct {
+	ID      int32    `json:"id" yaml:"id"`
+	Title   string   `json:"title" yaml:"title"`
+	MS      string   `json:"ms" yaml:"ms"`
+	Value   int32    `json:"value" yaml:"value"`
+	TitleEN string   `json:"title_en,omitempty" yaml:"title_en,omitempty"`
+	MSEN    string   `json:"ms_en,omitempty" yaml:"ms_en,omitempty"`
+	Romaji  string   `json:"romaji,omitempty" yaml:"romaji,omitempty"`
+	Cost    int      `json:"cost,omitempty" yaml:"cost,omitempty"`
+	DLC     bool     `json:"dlc,omitempty" yaml:"dlc,omitempty"`
+	Version string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Tags    []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Hide    bool     `json:"hide,omitempty" yaml:"hide,omitempty"`
+}
+
+func (e entry) change() Change {
+	c := Change{Hide: e.Hide, Unit: Unit{
+		ID:      e.ID,
+		Title:   e.Title,
+		MS:      e.MS,
+		Value:   e.Value,
+		TitleEN: e.TitleEN,
+		MSEN:    e.MSEN,
+		Romaji:  e.Romaji,
+		Cost:    e.Cost,
+		DLC:     e.DLC,
+		Version: e.Version,
+	}}
+	for _, t := range e.Tags {
+		c.Tags = append(c.Tags, strings.ToLower(t))
+	}
+	return c
+}
+
+// fromRecords converts CSV/TSV rows. Columns are located by the header so
+// the optional ones may appear in any order (a header naming no known column
+// is taken as id,title,ms,value); rows whose id or value is not a number
+// are skipped.
+func fromRecords(records [][]string, filename string, override bool) []Change {
+	col := map[string]int{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+		for _, c := range columns {
+			if name == c {
+				col[c] = i
+			}
+		}
+	}
+	if len(col) == 0 {
+		col = map[string]int{"id": 0, "title": 1, "ms": 2, "value": 3}
+	}
+	field := func(record []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var changes []Change
+	for i, record := range records[1:] {
+		if len(record) < 4 && !override {
+			continue
+		}
+		id, err := strconv.Atoi(field(record, "id"))
+		if override && field(record, "id") == "" {
+			id, err = 0, nil
+		}
+		if err != nil {
+			slog.Warn("⚠️ Skipping row with invalid id", "file", filename, "row", i+2, "id", field(record, "id"))
+			continue
+		}
+		value, err := strconv.Atoi(field(record, "value"))
+		if err != nil {
+			slog.Warn("⚠️ Skipping row with invalid value", "file", filename, "row", i+2, "value", field(record, "value"))
+			continue
+		}
+		u := Unit{
+			ID:      int32(id),
+			Title:   field(record, "title"),
+			MS:      field(record, "ms"),
+			Value:   int32(value),
+			TitleEN: field(record, "title_en"),
+			MSEN:    field(record, "ms_en"),
+			Romaji:  field(record, "romaji"),
+			Version: field(record, "version"),
+			Tags:    splitTags(field(record, "tags")),
+		}
+		if c := field(record, "cost"); c != "" {
+			if u.Cost, err = strconv.Atoi(c); err != nil {
+				slog.Warn("⚠️ Ignoring invalid cost", "file", filename, "row", i+2, "cost", c)
+			}
+		}
+		u.DLC = parseBool(field(record, "dlc"))
+		changes = append(changes, Change{Unit: u, Hide: parseBool(field(record, "hide"))})
+	}
+	return changes
+}
+
+// splitTags splits a tags cell such as "melee|zoning" (spaces and ; also
+// separate tags).
+func splitTags(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == '|' || r == ';' || r == ' '
+	})
+}
+
+func parseBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "1", "true", "yes", "y", "dlc":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package unitlist
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestSearchFieldShortcuts(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+
+	stop := &desktop.CustomShortcut{KeyName: fyne.KeyBackspace, Modifier: fyne.KeyModifierControl}
+	palette := &desktop.CustomShortcut{KeyName: fyne.KeyK, Modifier: fyne.KeyModifierControl}
+	var ran []string
+	e := NewSearchField()
+	e.OnShortcut = func(sc fyne.Shortcut) bool {
+		switch sc.ShortcutName() {
+		case stop.ShortcutName(), palette.ShortcutName():
+			ran = append(ran, sc.ShortcutName())
+			return true
+		}
+		return false
+	}
+	w := test.NewWindow(e)
+	defer w.Close()
+	w.Canvas().Focus(e)
+	test.Type(e, "zaku gundam")
+
+	// A hotkey runs its action and leaves the text alone, although the
+	// Entry would delete a word on Ctrl+Backspace.
+	e.TypedShortcut(stop)
+	e.TypedShortcut(palette)
+	if len(ran) != 2 || e.Text != "zaku gundam" {
+		t.Errorf("hotkeys: ran %v, text %q", ran, e.Text)
+	}
+
+	// Other shortcuts still reach the Entry.
+	e.OnShortcut = func(fyne.Shortcut) bool { return false }
+	e.TypedShortcut(stop)
+	if e.Text != "zaku " {
+		t.Errorf("Ctrl+Backspace without a hotkey left %q, want the last word deleted", e.Text)
+	}
+	e.TypedShortcut(&fyne.ShortcutSelectAll{})
+	if e.SelectedText() != "zaku " {
+		t.Errorf("select all selected %q", e.SelectedText())
+	}
+}
+
+func TestSearchFieldKeys(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+
+	var moved []fyne.KeyName
+	e := NewSearchField()
+	e.OnKey = func(key fyne.KeyName) bool {
+		if key == fyne.KeyDown || key == fyne.KeyUp {
+			moved = append(moved, key)
+			return true
+		}
+		return false
+	}
+	w := test.NewWindow(e)
+	defer w.Close()
+	w.Canvas().Focus(e)
+	test.Type(e, "zaku")
+	e.TypedKey(&fyne.KeyEvent{Name: fyne.KeyDown})
+	e.TypedKey(&fyne.KeyEvent{Name: fyne.KeyBackspace})
+	if len(moved) != 1 || e.Text != "zak" {
+		t.Errorf("moved %v, text %q", moved, e.Text)
+	}
+}
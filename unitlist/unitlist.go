@@ -0,0 +1,362 @@
+// Package unitlist is the Mobile Suit list of the GUI, a single virtualized
+// widget.List with sortable column headers, optionally grouped by series,
+// and the search box that drives it.
+package unitlist
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"ms-changer/i18n"
+	"ms-changer/unitdb"
+)
+
+// columns are the columns of the list.
+var columns = []struct {
+	name  string
+	width float32
+}{
+	{"Title", 250}, {"Mobile Suit", 270}, {"Value", 100}, {"Cost", 60}, {"Tags", 150},
+}
+
+// row is a row of the list: a unit, or a series heading when the list is
+// grouped.
+type row struct {
+	unit   *unitdb.Unit
+	header string
+	title  string // series title of a heading
+}
+
+// Table is the Mobile Suit list. The selected unit is remembered by value,
+// so it survives filter and sort changes.
+type Table struct {
+	List     *widget.List
+	Units    []unitdb.Unit // after filtering, in database or search order
+	Selected int32         // value of the selected unit, 0 = none
+
+	headers  []*widget.Button
+	rows     []row
+	sortCol  int // index into columns, -1 = database order
+	sortDesc bool
+	grouped  bool
+	series   func() *unitdb.Catalog
+	onSelect func(unitdb.Unit)
+	quiet    bool // set while reselect highlights a row, which is not a pick
+}
+
+// New returns an empty list, grouped by the series that series returns.
+// onSelect is called when the user picks a unit.
+func New(series func() *unitdb.Catalog, onSelect func(unitdb.Unit)) *Table {
+	t := &Table{sortCol: -1, grouped: true, series: series, onSelect: onSelect}
+	t.List = widget.NewList(
+		func() int { return len(t.rows) },
+		func() fyne.CanvasObject {
+			cells := make([]fyne.CanvasObject, len(columns))
+			for i := range cells {
+				l := widget.NewLabel("")
+				l.Truncation = fyne.TextTruncateEllipsis
+				cells[i] = l
+			}
+			return container.New(columnsLayout{}, cells...)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			cells := item.(*fyne.Container).Objects
+			row := t.rows[id]
+			for i, c := range cells {
+				l := c.(*widget.Label)
+				l.TextStyle.Bold = row.unit == nil
+				if row.unit == nil {
+					// headings span the whole row
+					l.SetText(row.header)
+					if i > 0 {
+						l.Hide()
+					}
+					continue
+				}
+				l.Show()
+				l.SetText(t.cell(*row.unit, i))
+			}
+			item.(*fyne.Container).Refresh()
+		},
+	)
+	t.List.OnSelected = func(id widget.ListItemID) {
+		if t.quiet {
+			return
+		}
+		if id >= len(t.rows) || t.rows[id].unit == nil {
+			t.reselect()
+			return
+		}
+		u := *t.rows[id].unit
+		t.Selected = u.Value
+		t.onSelect(u)
+	}
+	for i, c := range columns {
+		i := i
+		b := widget.NewButton(i18n.T(c.name), func() { t.sortBy(i) })
+		b.Alignment = widget.ButtonAlignLeading
+		b.Importance = widget.LowImportance
+		t.headers = append(t.headers, b)
+	}
+	return t
+}
+
+// Header returns the row of column buttons; tapping one sorts by it,
+// ascending, then descending, then back to database order.
+func (t *Table) Header() fyne.CanvasObject {
+	cells := make([]fyne.CanvasObject, len(t.headers))
+	for i, b := range t.headers {
+		cells[i] = b
+	}
+	return container.New(columnsLayout{}, cells...)
+}
+
+func (t *Table) cell(u unitdb.Unit, col int) string {
+	lang := i18n.Language()
+	switch col {
+	case 0:
+		if s, ok := t.series().Lookup(u.Title); ok && s.Short != "" {
+			return s.Icon + " " + s.Short
+		}
+		return u.DisplayTitle(lang)
+	case 1:
+		return "🤖 " + u.DisplayName(lang)
+	case 2:
+		return strconv.Itoa(int(u.Value))
+	case 3:
+		if u.Cost == 0 {
+			return ""
+		}
+		return strconv.Itoa(u.Cost)
+	}
+	tags := u.Tags
+	if u.DLC {
+		tags = append([]string{"DLC"}, tags...)
+	}
+	return strings.Join(tags, ", ")
+}
+
+func (t *Table) sortBy(col int) {
+	switch {
+	case t.sortCol != col:
+		t.sortCol, t.sortDesc = col, false
+	case !t.sortDesc:
+		t.sortDesc = true
+	default:
+		t.sortCol = -1
+	}
+	for i, b := range t.headers {
+		text := i18n.T(columns[i].name)
+		if i == t.sortCol {
+			text += map[bool]string{false: " ▲", true: " ▼"}[t.sortDesc]
+		}
+		b.SetText(text)
+	}
+	t.Update(t.Units)
+	t.ScrollToSelected()
+}
+
+// SetGrouped groups the units by series, or lists them without headings.
+func (t *Table) SetGrouped(grouped bool) {
+	t.grouped = grouped
+	t.Update(t.Units)
+	t.ScrollToSelected()
+}
+
+// Show replaces the units shown, like Update, and keeps the selected unit
+// in view; when it is filtered out the list starts from the top.
+func (t *Table) Show(units []unitdb.Unit) {
+	t.Update(units)
+	if t.Row() >= 0 {
+		t.ScrollToSelected()
+	} else {
+		t.List.ScrollToTop()
+	}
+}
+
+// Update replaces the units shown.
+func (t *Table) Update(units []unitdb.Unit) {
+	t.Units = units
+	sorted := func(units []unitdb.Unit) []unitdb.Unit {
+		if t.sortCol < 0 {
+			return units
+		}
+		units = slices.Clone(units)
+		sort.SliceStable(units, func(i, j int) bool {
+			a, b := units[i], units[j]
+			if t.sortDesc {
+				a, b = b, a
+			}
+			switch t.sortCol {
+			case 2:
+				return a.Value < b.Value
+			case 3:
+				return a.Cost < b.Cost
+			}
+			return t.cell(a, t.sortCol) < t.cell(b, t.sortCol)
+		})
+		return units
+	}
+
+	lang := i18n.Language()
+	t.rows = nil
+	if t.grouped {
+		for _, g := range t.series().Group(units) {
+			t.rows = append(t.rows, row{header: fmt.Sprintf("%s %s (%d)", g.Icon, g.DisplayName(lang), len(g.Units)), title: g.Title})
+			for _, u := range sorted(g.Units) {
+				t.rows = append(t.rows, row{unit: &u})
+			}
+		}
+	} else {
+		for _, u := range sorted(units) {
+			t.rows = append(t.rows, row{unit: &u})
+		}
+	}
+	t.List.Refresh()
+	t.reselect()
+}
+
+// Row returns the row of the selected unit, or -1 when it is not shown.
+func (t *Table) Row() int {
+	for i, r := range t.rows {
+		if r.unit != nil && r.unit.Value == t.Selected {
+			return i
+		}
+	}
+	return -1
+}
+
+// reselect highlights the selected unit if it is shown. It does not call
+// onSelect: the unit is already the selected one.
+func (t *Table) reselect() {
+	t.quiet = true
+	defer func() { t.quiet = false }()
+	if i := t.Row(); i >= 0 {
+		t.List.Select(i)
+	} else {
+		t.List.UnselectAll()
+	}
+}
+
+// ScrollToSelected scrolls to the selected unit if it is shown.
+func (t *Table) ScrollToSelected() {
+	if i := t.Row(); i >= 0 {
+		t.List.ScrollTo(i)
+	}
+}
+
+// ScrollToTitle scrolls to the heading, or the first unit, of a series. It
+// reports false when no unit of the series is shown.
+func (t *Table) ScrollToTitle(title string) bool {
+	for i, r := range t.rows {
+		if r.title == title || r.unit != nil && r.unit.Title == title {
+			t.List.ScrollTo(i)
+			return true
+		}
+	}
+	return false
+}
+
+// SelectValue selects the unit with the given value, 0 clearing the
+// selection.
+func (t *Table) SelectValue(value int32) {
+	t.Selected = value
+	t.reselect()
+}
+
+// Move selects the unit delta units away from the selected one, or the
+// first unit when none is shown; headings are skipped.
+func (t *Table) Move(delta int) {
+	var rows []int // rows that hold units
+	cur := -1
+	for i, r := range t.rows {
+		if r.unit != nil {
+			if r.unit.Value == t.Selected {
+				cur = len(rows)
+			}
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	next := 0
+	if cur >= 0 {
+		next = min(max(cur+delta, 0), len(rows)-1)
+	}
+	t.List.Select(rows[next])
+	t.List.ScrollTo(rows[next])
+}
+
+// columnsLayout places its objects side by side at the widths of columns;
+// the last visible object takes the remaining width.
+type columnsLayout struct{}
+
+func (columnsLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	last := 0
+	for i, o := range objects {
+		if o.Visible() {
+			last = i
+		}
+	}
+	x := float32(0)
+	for i, o := range objects {
+		if !o.Visible() {
+			continue
+		}
+		w := columns[i].width
+		if i == last {
+			w = max(size.Width-x, 0)
+		}
+		o.Move(fyne.NewPos(x, 0))
+		o.Resize(fyne.NewSize(w, size.Height))
+		x += w
+	}
+}
+
+func (columnsLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	var size fyne.Size
+	for i, o := range objects {
+		size.Width += columns[i].width
+		size.Height = max(size.Height, o.MinSize().Height)
+	}
+	return size
+}
+
+// SearchField is the search box above the list. OnKey gets the keys first,
+// so the arrow and page keys can move the selection in the list instead of
+// the cursor; OnShortcut gets the shortcuts first, so the window's hotkeys
+// work while the box has the focus. Each reports whether it handled the
+// key.
+type SearchField struct {
+	widget.Entry
+	OnKey      func(fyne.KeyName) bool
+	OnShortcut func(fyne.Shortcut) bool
+}
+
+func NewSearchField() *SearchField {
+	e := &SearchField{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *SearchField) TypedKey(ev *fyne.KeyEvent) {
+	if e.OnKey != nil && e.OnKey(ev.Name) {
+		return
+	}
+	e.Entry.TypedKey(ev)
+}
+
+func (e *SearchField) TypedShortcut(sc fyne.Shortcut) {
+	if e.OnShortcut != nil && e.OnShortcut(sc) {
+		return
+	}
+	e.Entry.TypedShortcut(sc)
+}
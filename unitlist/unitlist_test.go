@@ -0,0 +1,123 @@
+package unitlist
+
+import (
+	"fmt"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"ms-changer/unitdb"
+)
+
+// testUnits returns n units over two titles.
+func testUnits(n int) []unitdb.Unit {
+	var units []unitdb.Unit
+	for i := 1; i <= n; i++ {
+		title := "機動戦士ガンダム"
+		if i > n/2 {
+			title = "機動戦士Zガンダム"
+		}
+		units = append(units, unitdb.Unit{ID: int32(i), Title: title, MS: fmt.Sprintf("MS %d", i), Value: int32(1000 + i)})
+	}
+	return units
+}
+
+// newTestTable shows t in a window small enough to scroll and records the
+// units picked.
+func newTestTable(t *testing.T) (*Table, *[]int32) {
+	t.Helper()
+	a := test.NewApp()
+	t.Cleanup(a.Quit)
+	picked := &[]int32{}
+	table := New(func() *unitdb.Catalog { return nil }, func(u unitdb.Unit) {
+		*picked = append(*picked, u.Value)
+	})
+	w := test.NewWindow(table.List)
+	w.Resize(fyne.NewSize(600, 200))
+	t.Cleanup(w.Close)
+	return table, picked
+}
+
+func TestShowKeepsSelectionInView(t *testing.T) {
+	table, _ := newTestTable(t)
+	units := testUnits(100)
+	table.Show(units)
+	table.SelectValue(1090)
+	table.ScrollToSelected()
+	far := table.List.GetScrollOffset()
+	if far == 0 {
+		t.Fatal("the list did not scroll to the selected unit")
+	}
+
+	// Typing in the search box: the selected unit is still listed.
+	table.Show(units[40:])
+	if got := table.List.GetScrollOffset(); got == 0 {
+		t.Error("Show scrolled to the top although the selected unit is shown")
+	}
+	if table.Row() < 0 {
+		t.Error("the selected unit is not highlighted")
+	}
+
+	// Filtered out: start from the top, keep remembering the selection.
+	table.Show(units[:10])
+	if got := table.List.GetScrollOffset(); got != 0 {
+		t.Errorf("scroll offset %v, want the top when the selected unit is filtered out", got)
+	}
+	if table.Selected != 1090 {
+		t.Errorf("Selected = %d, want 1090 to survive the filter", table.Selected)
+	}
+	table.Show(units)
+	if table.Row() < 0 || table.List.GetScrollOffset() == 0 {
+		t.Error("the selected unit is not back in view once the filter is cleared")
+	}
+}
+
+func TestReselectIsNotAPick(t *testing.T) {
+	table, picked := newTestTable(t)
+	units := testUnits(20)
+	table.Show(units)
+	table.SelectValue(1005)
+	table.Show(units[2:])
+	table.SetGrouped(false)
+	table.sortBy(2)
+	table.SelectValue(1007)
+	if len(*picked) != 0 {
+		t.Fatalf("programmatic selection called onSelect with %v", *picked)
+	}
+
+	// Keyboard moves and clicks are picks.
+	table.Move(1)
+	table.List.Select(0)
+	if want := []int32{1008, 1003}; fmt.Sprint(*picked) != fmt.Sprint(want) {
+		t.Errorf("picked %v, want %v (the next unit, then the first row)", *picked, want)
+	}
+}
+
+func TestGrouped(t *testing.T) {
+	table, picked := newTestTable(t)
+	table.Show(testUnits(10))
+	if len(table.rows) != 12 || table.rows[0].unit != nil || table.rows[6].unit != nil {
+		t.Fatalf("grouped rows = %d, want a heading before each title's 5 units", len(table.rows))
+	}
+
+	// Clicking a heading keeps the selection.
+	table.SelectValue(1002)
+	table.List.Select(0)
+	if len(*picked) != 0 || table.Row() != 2 {
+		t.Errorf("heading click: picked %v, selected row %d", *picked, table.Row())
+	}
+	// Move skips headings.
+	table.SelectValue(1005)
+	table.Move(1)
+	if table.Selected != 1006 || table.Row() != 7 {
+		t.Errorf("Move(1) from the last unit of a title selected %d at row %d", table.Selected, table.Row())
+	}
+
+	if !table.ScrollToTitle("機動戦士Zガンダム") {
+		t.Error("ScrollToTitle did not find a listed title")
+	}
+	if table.ScrollToTitle("機動戦士ガンダムZZ") {
+		t.Error("ScrollToTitle found a title that is not listed")
+	}
+}
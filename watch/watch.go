@@ -0,0 +1,128 @@
+// Package watch reports changes to a set of files, such as the unit
+// database and pointer profile, so they can be reloaded while running.
+package watch
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Debounce is how long a burst of events (editors often write a file in
+// several steps) has to settle before onChange is called.
+var Debounce = 300 * time.Millisecond
+
+// PollInterval is used when file system notifications are not available.
+var PollInterval = 2 * time.Second
+
+// Files calls onChange after any of paths is created, written, renamed or
+// removed, until ctx is done. Paths need not exist yet. The parent
+// directories are watched, since editors often replace a file instead of
+// writing it; when that fails Files falls back to polling.
+func Files(ctx context.Context, paths []string, onChange func()) {
+	watched := map[string]bool{}
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			watched[abs] = true
+		}
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err == nil {
+		added := 0
+		for p := range watched {
+			if w.Add(filepath.Dir(p)) == nil {
+				added++
+			}
+		}
+		if added == 0 {
+			w.Close()
+			err = os.ErrNotExist
+		}
+	}
+	if err != nil {
+		slog.Debug("file notifications unavailable, polling", "err", err)
+		poll(ctx, watched, onChange)
+		return
+	}
+	defer w.Close()
+
+	timer := time.NewTimer(Debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if relevant(watched, ev) {
+				slog.Debug("file changed", "file", ev.Name, "op", ev.Op.String())
+				timer.Reset(Debounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("⚠️ File watcher error, polling instead", "err", err)
+			w.Close()
+			poll(ctx, watched, onChange)
+			return
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
+
+// relevant reports whether ev changes one of the watched files. Only a bare
+// chmod is ignored; some platforms report a write as Write|Chmod.
+func relevant(watched map[string]bool, ev fsnotify.Event) bool {
+	abs, err := filepath.Abs(ev.Name)
+	return err == nil && watched[abs] && ev.Op != fsnotify.Chmod
+}
+
+// state is what polling compares: a missing file has the zero state.
+type state struct {
+	size    int64
+	modTime time.Time
+}
+
+func stat(path string) state {
+	info, err := os.Stat(path)
+	if err != nil {
+		return state{}
+	}
+	return state{info.Size(), info.ModTime()}
+}
+
+func poll(ctx context.Context, watched map[string]bool, onChange func()) {
+	last := map[string]state{}
+	for p := range watched {
+		last[p] = stat(p)
+	}
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed := false
+			for p := range watched {
+				if s := stat(p); s != last[p] {
+					last[p] = s
+					changed = true
+				}
+			}
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
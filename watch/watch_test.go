@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestRelevant(t *testing.T) {
+	dir := t.TempDir()
+	units := filepath.Join(dir, "units.csv")
+	watched := map[string]bool{units: true}
+	tests := []struct {
+		name string
+		op   fsnotify.Op
+		want bool
+	}{
+		{units, fsnotify.Write, true},
+		{units, fsnotify.Write | fsnotify.Chmod, true},
+		{units, fsnotify.Create, true},
+		{units, fsnotify.Rename, true},
+		{units, fsnotify.Remove, true},
+		{units, fsnotify.Chmod, false},
+		{filepath.Join(dir, "other.csv"), fsnotify.Write, false},
+	}
+	for _, tt := range tests {
+		if got := relevant(watched, fsnotify.Event{Name: tt.name, Op: tt.op}); got != tt.want {
+			t.Errorf("relevant(%s %s) = %v, want %v", filepath.Base(tt.name), tt.op, got, tt.want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	old := Debounce
+	Debounce = 20 * time.Millisecond
+	t.Cleanup(func() { Debounce = old })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "units.csv")
+	changed := make(chan struct{}, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Files(ctx, []string{path}, func() { changed <- struct{}{} })
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	time.Sleep(50 * time.Millisecond) // let the watcher start
+
+	expect := func(what string, want bool) {
+		t.Helper()
+		select {
+		case <-changed:
+			if !want {
+				t.Errorf("%s: onChange called", what)
+			}
+		case <-time.After(500 * time.Millisecond):
+			if want {
+				t.Errorf("%s: onChange not called", what)
+			}
+		}
+	}
+	os.WriteFile(path, []byte("id,title,ms,value\n"), 0644)
+	expect("create", true)
+	os.WriteFile(path, []byte("id,title,ms,value\n1,a,b,1001001\n"), 0644)
+	expect("write", true)
+	os.Chmod(path, 0600)
+	expect("chmod", false)
+	os.WriteFile(filepath.Join(dir, "other.csv"), nil, 0644)
+	expect("another file", false)
+	os.Remove(path)
+	expect("remove", true)
+}